@@ -0,0 +1,305 @@
+package ast
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/basemax/goscript/lexer"
+)
+
+// Node is any syntax tree node produced by the parser.
+type Node interface {
+	node()
+}
+
+type ArrayLiteral struct {
+	Elements []Node
+}
+
+type TupleLiteral struct {
+	Elements []Node
+}
+
+type StringLiteral struct {
+	Lexeme lexer.Lexeme
+	Value  string
+}
+
+type Ident struct {
+	Lexeme lexer.Lexeme
+	IsFunc bool
+}
+
+type IntegerLiteral struct {
+	Lexeme lexer.Lexeme
+	Value  int
+	Big    *big.Int
+}
+
+type DecimalLiteral struct {
+	Lexeme lexer.Lexeme
+}
+
+type FloatLiteral struct {
+	Lexeme lexer.Lexeme
+	Value  float64
+}
+type BooleanLiteral struct {
+	Lexeme lexer.Lexeme
+	Value  bool
+}
+
+type ReturnStmt struct {
+	Expr Node
+}
+type VarAssign struct {
+	Name  Node
+	Value Node
+}
+type PrefixOp struct {
+	Lexeme lexer.Lexeme
+	Expr   Node
+}
+
+type InfixOp struct {
+	Lexeme lexer.Lexeme
+	Left   Node
+	Right  Node
+}
+
+type IsExpr struct {
+	Value Node
+	Type  Node
+}
+
+type BlockStmt struct {
+	Stmts []Node
+}
+
+type IfStmt struct {
+	Condition Node
+	Then      *BlockStmt
+	Else      *BlockStmt
+}
+
+type ForStmt struct {
+	Key    *Ident
+	Value  *Ident
+	Target Node
+	Body   *BlockStmt
+}
+
+type RangeExpr struct {
+	From Node
+	To   Node
+	Step Node
+}
+
+type PrintStmt struct {
+	Args    []Node
+	NewLine bool
+}
+
+type IndexExpr struct {
+	Collection Node
+	Index      Node
+}
+
+type SliceExpr struct {
+	Collection Node
+	Start      Node
+	End        Node
+	Step       Node
+}
+
+type MemberExpr struct {
+	Object Node
+	Name   string
+}
+
+type MethodCall struct {
+	Object Node
+	Name   string
+	Args   []Node
+}
+
+type MapLiteral struct {
+	Pairs []MapPair
+}
+
+type MapPair struct {
+	Key   Node
+	Value Node
+}
+
+type FunctionLiteral struct {
+	Name        string
+	Params      []*Ident
+	ParamTypes  []*TypeSpec
+	ReturnType  *TypeSpec
+	Body        *BlockStmt
+	IsGenerator bool
+}
+
+// TypeSpec is a type annotation. Array, map and tuple annotations carry
+// their element types in Elems: [int], {string: int} and (int, string).
+type TypeSpec struct {
+	Name     string
+	Elems    []*TypeSpec
+	Optional bool
+}
+
+type LetStmt struct {
+	Name  *Ident
+	Type  *TypeSpec
+	Value Node
+}
+
+type YieldStmt struct {
+	Expr Node
+}
+
+type BreakStmt struct{}
+
+type NamedArg struct {
+	Name  string
+	Value Node
+}
+
+type StructDecl struct {
+	Name   string
+	Fields []string
+}
+
+type ClassDecl struct {
+	Name    string
+	Parent  *Ident
+	Methods []FunctionLiteral
+}
+
+type EnumDecl struct {
+	Name     string
+	Variants []EnumVariantDecl
+}
+
+type EnumVariantDecl struct {
+	Name   string
+	Fields []string
+}
+
+type MatchExpr struct {
+	Subject Node
+	Arms    []MatchArm
+}
+
+type MatchArm struct {
+	Pattern Node
+	Body    Node
+}
+
+type CallExpr struct {
+	Function Node
+	Args     []Node
+}
+
+type SwapStmt struct {
+	A Node
+	B Node
+}
+
+type ImportStmt struct {
+	File Node
+}
+
+type InputStmt struct {
+	Prompt Node
+}
+
+type LengthExpr struct {
+	Target Node
+}
+
+func (ArrayLiteral) node()    {}
+func (TupleLiteral) node()    {}
+func (StringLiteral) node()   {}
+func (Ident) node()           {}
+func (IntegerLiteral) node()  {}
+func (DecimalLiteral) node()  {}
+func (FloatLiteral) node()    {}
+func (BooleanLiteral) node()  {}
+func (ReturnStmt) node()      {}
+func (VarAssign) node()       {}
+func (PrefixOp) node()        {}
+func (InfixOp) node()         {}
+func (IsExpr) node()          {}
+func (BlockStmt) node()       {}
+func (IfStmt) node()          {}
+func (ForStmt) node()         {}
+func (RangeExpr) node()       {}
+func (PrintStmt) node()       {}
+func (IndexExpr) node()       {}
+func (SliceExpr) node()       {}
+func (MemberExpr) node()      {}
+func (MethodCall) node()      {}
+func (MapLiteral) node()      {}
+func (FunctionLiteral) node() {}
+func (LetStmt) node()         {}
+func (YieldStmt) node()       {}
+func (BreakStmt) node()       {}
+func (NamedArg) node()        {}
+func (StructDecl) node()      {}
+func (ClassDecl) node()       {}
+func (EnumDecl) node()        {}
+func (MatchExpr) node()       {}
+func (CallExpr) node()        {}
+func (SwapStmt) node()        {}
+func (ImportStmt) node()      {}
+func (InputStmt) node()       {}
+func (LengthExpr) node()      {}
+
+// BuiltinTypes are the canonical type names usable in annotations and is
+// checks, besides user declared types and any.
+var BuiltinTypes = map[string]bool{
+	"nil":       true,
+	"int":       true,
+	"float":     true,
+	"decimal":   true,
+	"string":    true,
+	"bool":      true,
+	"array":     true,
+	"tuple":     true,
+	"map":       true,
+	"function":  true,
+	"type":      true,
+	"generator": true,
+	"iterator":  true,
+}
+
+func (fn FunctionLiteral) ParamType(i int) *TypeSpec {
+	if i < len(fn.ParamTypes) {
+		return fn.ParamTypes[i]
+	}
+	return nil
+}
+
+func (t *TypeSpec) String() string {
+	var s string
+	switch {
+	case t.Name == "array" && len(t.Elems) == 1:
+		s = "[" + t.Elems[0].String() + "]"
+	case t.Name == "map" && len(t.Elems) == 2:
+		s = "{" + t.Elems[0].String() + ": " + t.Elems[1].String() + "}"
+	case t.Name == "tuple" && len(t.Elems) > 0:
+		parts := make([]string, len(t.Elems))
+		for i, el := range t.Elems {
+			parts[i] = el.String()
+		}
+		s = "(" + strings.Join(parts, ", ") + ")"
+	default:
+		s = t.Name
+	}
+	if t.Optional {
+		s += "?"
+	}
+	return s
+}
@@ -0,0 +1,616 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/basemax/goscript/ast"
+)
+
+// checker infers types over the AST without running it. Only values whose
+// types are known statically are checked; everything else is left to the
+// runtime checks. Unannotated scripts are checked only where literal types
+// alone prove a mistake, as in "s" - 1.
+type checker struct {
+	scopes  []map[string]checkedVar
+	funcs   map[string]ast.FunctionLiteral
+	types   map[string]string
+	current *ast.FunctionLiteral
+	errors  []error
+	// line is the last source line seen, for errors about nodes that
+	// carry no position of their own, such as type annotations.
+	line int
+}
+
+type checkedVar struct {
+	Type     *ast.TypeSpec
+	Declared bool
+}
+
+var builtinResults = map[string]*ast.TypeSpec{
+	"type":     {Name: "string"},
+	"str":      {Name: "string"},
+	"int":      {Name: "int"},
+	"float":    {Name: "float"},
+	"bool":     {Name: "bool"},
+	"decimal":  {Name: "decimal"},
+	"tuple":    {Name: "tuple"},
+	"lines":    {Name: "iterator"},
+	"iter":     {Name: "iterator"},
+	"readfile": {Name: "string"},
+	"exec":     {Name: "string"},
+	"now":      {Name: "float"},
+}
+
+// Check statically type checks a program and returns every mismatch found.
+func Check(nodes []ast.Node) []error {
+	c := &checker{
+		scopes: []map[string]checkedVar{{}},
+		funcs:  map[string]ast.FunctionLiteral{},
+		types:  map[string]string{},
+	}
+	for _, node := range nodes {
+		c.declare(node)
+	}
+	for _, node := range nodes {
+		c.expr(node)
+	}
+	return c.errors
+}
+
+// errorf records an error at the line of node, or at the last line seen
+// when node has none.
+func (c *checker) errorf(node ast.Node, format string, args ...any) {
+	line := lineOf(node)
+	if line == 0 {
+		line = c.line
+	}
+	if c.current != nil {
+		format = c.current.Name + ": " + format
+	}
+	c.errors = append(c.errors, fmt.Errorf("line %d: "+format, append([]any{line}, args...)...))
+}
+
+// lineOf returns the line of the first token of node that the parser kept,
+// or 0 if it kept none.
+func lineOf(node ast.Node) int {
+	switch n := node.(type) {
+	case ast.Ident:
+		return n.Lexeme.Line
+	case *ast.Ident:
+		if n != nil {
+			return n.Lexeme.Line
+		}
+	case ast.IntegerLiteral:
+		return n.Lexeme.Line
+	case ast.FloatLiteral:
+		return n.Lexeme.Line
+	case ast.DecimalLiteral:
+		return n.Lexeme.Line
+	case ast.StringLiteral:
+		return n.Lexeme.Line
+	case ast.BooleanLiteral:
+		return n.Lexeme.Line
+	case ast.PrefixOp:
+		return n.Lexeme.Line
+	case ast.InfixOp:
+		return lineOf(n.Left)
+	case ast.IsExpr:
+		return lineOf(n.Value)
+	case ast.LetStmt:
+		return lineOf(n.Name)
+	case ast.VarAssign:
+		return lineOf(n.Name)
+	case ast.ReturnStmt:
+		return lineOf(n.Expr)
+	case ast.YieldStmt:
+		return lineOf(n.Expr)
+	case ast.IfStmt:
+		return lineOf(n.Condition)
+	case ast.ForStmt:
+		return lineOf(n.Key)
+	case ast.RangeExpr:
+		return lineOf(n.From)
+	case ast.IndexExpr:
+		return lineOf(n.Collection)
+	case ast.SliceExpr:
+		return lineOf(n.Collection)
+	case ast.MemberExpr:
+		return lineOf(n.Object)
+	case ast.MethodCall:
+		return lineOf(n.Object)
+	case ast.CallExpr:
+		return lineOf(n.Function)
+	case ast.NamedArg:
+		return lineOf(n.Value)
+	case ast.LengthExpr:
+		return lineOf(n.Target)
+	case ast.ArrayLiteral:
+		if len(n.Elements) > 0 {
+			return lineOf(n.Elements[0])
+		}
+	case ast.TupleLiteral:
+		if len(n.Elements) > 0 {
+			return lineOf(n.Elements[0])
+		}
+	}
+	return 0
+}
+
+func (c *checker) declare(node ast.Node) {
+	switch n := node.(type) {
+	case ast.FunctionLiteral:
+		c.funcs[n.Name] = n
+	case ast.StructDecl:
+		c.types[n.Name] = ""
+	case ast.EnumDecl:
+		c.types[n.Name] = ""
+	case ast.ClassDecl:
+		c.types[n.Name] = ""
+		if n.Parent != nil {
+			c.types[n.Name] = n.Parent.Lexeme.Text
+		}
+	}
+}
+
+func (c *checker) lookup(name string) (checkedVar, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if v, ok := c.scopes[i][name]; ok {
+			return v, true
+		}
+	}
+	return checkedVar{}, false
+}
+
+func (c *checker) assign(id ast.Ident, t *ast.TypeSpec) {
+	name := id.Lexeme.Text
+	scope := c.scopes[len(c.scopes)-1]
+	v, ok := scope[name]
+	switch {
+	case !ok:
+		scope[name] = checkedVar{Type: t}
+	case v.Declared:
+		if !c.assignable(v.Type, t) {
+			c.errorf(id, "cannot assign %s to %s of type %s", t, name, v.Type)
+		}
+	case t == nil || v.Type == nil || t.String() != v.Type.String():
+		scope[name] = checkedVar{}
+	}
+}
+
+func (c *checker) validate(t *ast.TypeSpec) bool {
+	if t == nil {
+		return true
+	}
+	valid := true
+	for _, el := range t.Elems {
+		valid = c.validate(el) && valid
+	}
+	if _, ok := c.types[t.Name]; !ok && !ast.BuiltinTypes[t.Name] && t.Name != "any" {
+		c.errorf(nil, "unknown type %s", t.Name)
+		return false
+	}
+	return valid
+}
+
+// assignable reports whether a value of type got may be stored where want
+// is expected. Unknown types are always assignable.
+func (c *checker) assignable(want, got *ast.TypeSpec) bool {
+	if want == nil || got == nil || want.Name == "any" {
+		return true
+	}
+	if got.Name == "nil" {
+		return want.Optional || want.Name == "nil"
+	}
+	if got.Name == "int" && (want.Name == "float" || want.Name == "decimal") {
+		return true
+	}
+	if want.Name != got.Name {
+		return c.subclass(got.Name, want.Name)
+	}
+	if len(want.Elems) == 0 || len(got.Elems) == 0 {
+		return true
+	}
+	if len(want.Elems) != len(got.Elems) {
+		return false
+	}
+	for i := range want.Elems {
+		if !c.assignable(want.Elems[i], got.Elems[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *checker) subclass(name, parent string) bool {
+	for seen := 0; name != "" && seen < len(c.types); seen++ {
+		name = c.types[name]
+		if name == parent {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) function(fn ast.FunctionLiteral) {
+	outer := c.current
+	c.current = &fn
+	c.scopes = append(c.scopes, map[string]checkedVar{})
+	for i, param := range fn.Params {
+		t := fn.ParamType(i)
+		c.line = param.Lexeme.Line
+		c.validate(t)
+		c.scopes[len(c.scopes)-1][param.Lexeme.Text] = checkedVar{Type: t, Declared: t != nil}
+	}
+	c.validate(fn.ReturnType)
+	c.expr(fn.Body)
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.current = outer
+}
+
+func (c *checker) call(fn ast.FunctionLiteral, args []ast.Node) *ast.TypeSpec {
+	positional := 0
+	for _, arg := range args {
+		i := positional
+		if named, ok := arg.(ast.NamedArg); ok {
+			i = -1
+			for j, param := range fn.Params {
+				if param.Lexeme.Text == named.Name {
+					i = j
+				}
+			}
+			arg = named.Value
+		} else {
+			positional++
+		}
+		t := c.expr(arg)
+		if i < 0 || i >= len(fn.Params) {
+			continue
+		}
+		if want := fn.ParamType(i); !c.assignable(want, t) {
+			c.errorf(arg, "%s: argument %s must be %s, got %s", fn.Name, fn.Params[i].Lexeme.Text, want, t)
+		}
+	}
+	if fn.IsGenerator {
+		return &ast.TypeSpec{Name: "generator"}
+	}
+	return fn.ReturnType
+}
+
+func (c *checker) exprs(nodes []ast.Node) []*ast.TypeSpec {
+	types := make([]*ast.TypeSpec, len(nodes))
+	for i, node := range nodes {
+		types[i] = c.expr(node)
+	}
+	return types
+}
+
+// common returns the shared type of a list of element types, or nil when
+// any of them is unknown. Mixed numbers widen to the highest rank and any
+// other mix becomes a union such as string|int, which only any accepts.
+func common(types []*ast.TypeSpec) *ast.TypeSpec {
+	if len(types) == 0 {
+		return nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	widest := types[0]
+	for _, t := range types {
+		if t == nil {
+			return nil
+		}
+		if name := t.String(); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		if isNumericType(t) && isNumericType(widest) && numericTypeRank(t) > numericTypeRank(widest) {
+			widest = t
+		}
+	}
+	if len(names) == 1 || (isNumericType(widest) && allNumeric(types)) {
+		return widest
+	}
+	return &ast.TypeSpec{Name: strings.Join(names, "|")}
+}
+
+func allNumeric(types []*ast.TypeSpec) bool {
+	for _, t := range types {
+		if !isNumericType(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func collection(name string, elems ...*ast.TypeSpec) *ast.TypeSpec {
+	for _, el := range elems {
+		if el == nil {
+			return &ast.TypeSpec{Name: name}
+		}
+	}
+	return &ast.TypeSpec{Name: name, Elems: elems}
+}
+
+func isNumericType(t *ast.TypeSpec) bool {
+	return numericTypeRank(t) >= 0
+}
+
+func numericTypeRank(t *ast.TypeSpec) int {
+	switch t.Name {
+	case "int":
+		return 0
+	case "float":
+		return 1
+	case "decimal":
+		return 2
+	}
+	return -1
+}
+
+func (c *checker) expr(node ast.Node) *ast.TypeSpec {
+	if line := lineOf(node); line > 0 {
+		c.line = line
+	}
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case ast.IntegerLiteral:
+		return &ast.TypeSpec{Name: "int"}
+	case ast.FloatLiteral:
+		return &ast.TypeSpec{Name: "float"}
+	case ast.DecimalLiteral:
+		return &ast.TypeSpec{Name: "decimal"}
+	case ast.StringLiteral:
+		return &ast.TypeSpec{Name: "string"}
+	case ast.BooleanLiteral:
+		return &ast.TypeSpec{Name: "bool"}
+	case ast.ArrayLiteral:
+		return collection("array", common(c.exprs(n.Elements)))
+	case ast.TupleLiteral:
+		return collection("tuple", c.exprs(n.Elements)...)
+	case ast.MapLiteral:
+		var keys, values []*ast.TypeSpec
+		for _, pair := range n.Pairs {
+			keys = append(keys, c.expr(pair.Key))
+			values = append(values, c.expr(pair.Value))
+		}
+		return collection("map", common(keys), common(values))
+	case ast.Ident:
+		name := n.Lexeme.Text
+		if v, ok := c.lookup(name); ok {
+			return v.Type
+		}
+		if _, ok := c.funcs[name]; ok {
+			return &ast.TypeSpec{Name: "function"}
+		}
+		if name == "nil" {
+			return &ast.TypeSpec{Name: "nil"}
+		}
+		return nil
+	case ast.LetStmt:
+		valid := c.validate(n.Type)
+		t := c.expr(n.Value)
+		if n.Value == nil {
+			t = &ast.TypeSpec{Name: "nil"}
+		}
+		if valid && !c.assignable(n.Type, t) {
+			c.errorf(n.Name, "cannot assign %s to %s of type %s", t, n.Name.Lexeme.Text, n.Type)
+		}
+		if n.Type == nil {
+			c.scopes[len(c.scopes)-1][n.Name.Lexeme.Text] = checkedVar{Type: t}
+		} else {
+			c.scopes[len(c.scopes)-1][n.Name.Lexeme.Text] = checkedVar{Type: n.Type, Declared: true}
+		}
+	case ast.VarAssign:
+		t := c.expr(n.Value)
+		if ident, ok := n.Name.(ast.Ident); ok {
+			c.assign(ident, t)
+		} else {
+			c.expr(n.Name)
+		}
+		return t
+	case ast.PrefixOp:
+		t := c.expr(n.Expr)
+		switch {
+		case t == nil:
+		case n.Lexeme.Text == "!" && t.Name != "bool":
+			c.errorf(n, "operator ! expects bool, got %s", t)
+		case n.Lexeme.Text == "-" && !isNumericType(t):
+			c.errorf(n, "operator - expects a number, got %s", t)
+		}
+		return t
+	case ast.InfixOp:
+		return c.infix(n)
+	case ast.IsExpr:
+		c.expr(n.Value)
+		return &ast.TypeSpec{Name: "bool"}
+	case ast.BlockStmt:
+		return c.expr(&n)
+	case *ast.BlockStmt:
+		for _, stmt := range n.Stmts {
+			c.expr(stmt)
+		}
+	case ast.IfStmt:
+		if t := c.expr(n.Condition); t != nil && t.Name != "bool" {
+			c.errorf(n.Condition, "if condition must be a bool, got %s", t)
+		}
+		c.expr(n.Then)
+		if n.Else != nil {
+			c.expr(n.Else)
+		}
+	case ast.ForStmt:
+		t := c.expr(n.Target)
+		key, value := elementTypes(t)
+		if n.Value != nil {
+			c.assign(*n.Key, key)
+			c.assign(*n.Value, value)
+		} else if t != nil && t.Name == "map" {
+			c.assign(*n.Key, key)
+		} else {
+			c.assign(*n.Key, value)
+		}
+		c.expr(n.Body)
+	case ast.RangeExpr:
+		c.expr(n.From)
+		c.expr(n.To)
+		c.expr(n.Step)
+		return collection("array", &ast.TypeSpec{Name: "int"})
+	case ast.PrintStmt:
+		c.exprs(n.Args)
+	case ast.ReturnStmt:
+		t := c.expr(n.Expr)
+		if c.current != nil && !c.current.IsGenerator && !c.assignable(c.current.ReturnType, t) {
+			c.errorf(n, "must return %s, got %s", c.current.ReturnType, t)
+		}
+	case ast.YieldStmt:
+		c.expr(n.Expr)
+	case ast.FunctionLiteral:
+		c.funcs[n.Name] = n
+		c.function(n)
+	case ast.ClassDecl:
+		for _, m := range n.Methods {
+			c.function(m)
+		}
+	case ast.CallExpr:
+		return c.callExpr(n)
+	case ast.NamedArg:
+		return c.expr(n.Value)
+	case ast.IndexExpr:
+		t := c.expr(n.Collection)
+		c.expr(n.Index)
+		switch {
+		case t == nil:
+		case t.Name == "string":
+			return t
+		case t.Name == "array" && len(t.Elems) == 1:
+			return t.Elems[0]
+		case t.Name == "map" && len(t.Elems) == 2:
+			return t.Elems[1]
+		}
+	case ast.SliceExpr:
+		t := c.expr(n.Collection)
+		c.expr(n.Start)
+		c.expr(n.End)
+		c.expr(n.Step)
+		return t
+	case ast.MemberExpr:
+		c.expr(n.Object)
+	case ast.MethodCall:
+		c.expr(n.Object)
+		c.exprs(n.Args)
+	case ast.MatchExpr:
+		c.expr(n.Subject)
+		for _, arm := range n.Arms {
+			c.expr(arm.Body)
+		}
+	case ast.LengthExpr:
+		c.expr(n.Target)
+		return &ast.TypeSpec{Name: "int"}
+	case ast.InputStmt:
+		c.expr(n.Prompt)
+		return &ast.TypeSpec{Name: "string"}
+	}
+	return nil
+}
+
+func (c *checker) callExpr(n ast.CallExpr) *ast.TypeSpec {
+	ident, ok := n.Function.(ast.Ident)
+	if !ok {
+		c.expr(n.Function)
+		c.exprs(n.Args)
+		return nil
+	}
+	name := ident.Lexeme.Text
+	if _, shadowed := c.lookup(name); shadowed {
+		c.exprs(n.Args)
+		return nil
+	}
+	if fn, ok := c.funcs[name]; ok {
+		return c.call(fn, n.Args)
+	}
+	args := c.exprs(n.Args)
+	if _, ok := c.types[name]; ok {
+		return &ast.TypeSpec{Name: name}
+	}
+	switch name {
+	case "round":
+		// round keeps the kind of number it is given.
+		if len(args) > 0 && args[0] != nil && isNumericType(args[0]) {
+			return args[0]
+		}
+		return nil
+	case "sorted":
+		if len(args) == 1 && args[0] != nil && (args[0].Name == "array" || args[0].Name == "map") {
+			return args[0]
+		}
+		return nil
+	}
+	return builtinResults[name]
+}
+
+func elementTypes(t *ast.TypeSpec) (*ast.TypeSpec, *ast.TypeSpec) {
+	intType := &ast.TypeSpec{Name: "int"}
+	switch {
+	case t == nil:
+	case t.Name == "string":
+		return intType, t
+	case t.Name == "array" && len(t.Elems) == 1:
+		return intType, t.Elems[0]
+	case t.Name == "array" || t.Name == "tuple":
+		return intType, nil
+	case t.Name == "map" && len(t.Elems) == 2:
+		return t.Elems[0], t.Elems[1]
+	}
+	return nil, nil
+}
+
+func (c *checker) infix(n ast.InfixOp) *ast.TypeSpec {
+	l, r := c.expr(n.Left), c.expr(n.Right)
+	op := n.Lexeme.Text
+	boolType := &ast.TypeSpec{Name: "bool"}
+	switch op {
+	case "==", "!=":
+		return boolType
+	case "and", "or":
+		for _, t := range []*ast.TypeSpec{l, r} {
+			if t != nil && t.Name != "bool" {
+				c.errorf(n, "operator %s expects bool operands, got %s", op, t)
+			}
+		}
+		return boolType
+	}
+	if l == nil || r == nil {
+		if op == "+" || op == "-" || op == "*" || op == "/" {
+			return nil
+		}
+		return boolType
+	}
+	if _, ok := c.types[l.Name]; ok {
+		return nil
+	}
+	switch {
+	case isNumericType(l) && isNumericType(r):
+		if op == "+" || op == "-" || op == "*" || op == "/" {
+			if numericTypeRank(l) >= numericTypeRank(r) {
+				return &ast.TypeSpec{Name: l.Name}
+			}
+			return &ast.TypeSpec{Name: r.Name}
+		}
+		return boolType
+	case l.Name == "string" && r.Name == "string":
+		if op == "+" {
+			return l
+		}
+		if op != "-" && op != "*" && op != "/" {
+			return boolType
+		}
+	case op == "+" && (l.Name == "string" && isConcatenableType(r) || r.Name == "string" && isConcatenableType(l)):
+		return &ast.TypeSpec{Name: "string"}
+	}
+	c.errorf(n, "invalid operation: %s %s %s", l, op, r)
+	return nil
+}
+
+func isConcatenableType(t *ast.TypeSpec) bool {
+	return isNumericType(t) || t.Name == "bool"
+}
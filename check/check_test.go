@@ -0,0 +1,65 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/basemax/goscript/parser"
+)
+
+func TestErrorLines(t *testing.T) {
+	src := `fn add(a: int, b: int) -> int { return a + b }
+fn wrong() -> int {
+  return "nope"
+}
+let xs: [string] = ["a"]
+add(1,
+  "x")
+y = "s" - 1
+let t: Thing = 1
+if 1 { }`
+	want := []string{
+		"line 3: wrong: must return int, got string",
+		"line 7: add: argument b must be int, got string",
+		"line 8: invalid operation: string - int",
+		"line 9: unknown type Thing",
+		"line 10: if condition must be a bool, got int",
+	}
+	nodes, err := parser.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := Check(nodes)
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors %v, want %d", len(errs), errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, err, want[i])
+		}
+	}
+}
+
+func TestBuiltinResults(t *testing.T) {
+	tests := []struct {
+		src  string
+		errs int
+	}{
+		{"let r: float = round(2.5)", 0},
+		{"let n: int = round(5)", 0},
+		{"let d: decimal = round(2.345d, 2)", 0},
+		{"m = {\"a\": 1}\nlet s: {string: int} = sorted(m)", 0},
+		{"let xs: [int] = sorted([3, 1])", 0},
+		{"let s: string = round(5)", 1},
+		{"let s: string = str(5)", 0},
+		{"let n: int = str(5)", 1},
+	}
+	for _, tt := range tests {
+		nodes, err := parser.Parse(tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := Check(nodes); len(errs) != tt.errs {
+			t.Errorf("%q: got errors %v, want %d", tt.src, errs, tt.errs)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/basemax/goscript"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Printf("Usage: %s [check] <file>\n", os.Args[0])
+		return
+	}
+	if os.Args[1] == "check" && len(os.Args) > 2 {
+		checkFile(os.Args[2])
+		return
+	}
+
+	fileContent, fileErr := os.ReadFile(os.Args[1])
+	if fileErr != nil {
+		log.Fatal(fileErr)
+	}
+	src := string(fileContent)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	in := goscript.New()
+	if _, err := in.Run(ctx, src); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func checkFile(path string) {
+	fileContent, fileErr := os.ReadFile(path)
+	if fileErr != nil {
+		log.Fatal(fileErr)
+	}
+	errs := goscript.Check(string(fileContent))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,555 @@
+package goscript
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/basemax/goscript/eval"
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+	bigIntType   = reflect.TypeFor[*big.Int]()
+	evalPkgPath  = reflect.TypeFor[eval.Map]().PkgPath()
+)
+
+// ToValue converts a Go value to a script value. Structs become maps keyed
+// by field name, or by the name in a `gos:"name"` tag; `gos:"-"` skips a
+// field. Slices and arrays become arrays, maps become maps with sorted
+// keys, pointers are followed, time.Time becomes an RFC 3339 string and
+// time.Duration an int of nanoseconds.
+func ToValue(v any) (Value, error) {
+	return toValue(reflect.ValueOf(v))
+}
+
+// FromValue stores the script value v in the Go value target points to,
+// reversing ToValue. Struct fields missing from v keep their current
+// values, so target can be pre-filled with defaults. Empty interfaces
+// receive plain Go values: []any, map[string]any or map[any]any.
+func FromValue(v Value, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("FromValue target must be a non-nil pointer")
+	}
+	return assignValue(rv.Elem(), v)
+}
+
+func toValue(rv reflect.Value) (Value, error) {
+	return (&converter{}).value(rv)
+}
+
+// converter tracks the pointers, maps and slices on the path being
+// converted, so that a cyclic Go value is reported instead of recursing
+// forever. Values shared without a cycle are converted once per use.
+type converter struct {
+	path map[visit]bool
+}
+
+type visit struct {
+	ptr unsafe.Pointer
+	typ reflect.Type
+	len int
+}
+
+func visitOf(rv reflect.Value) visit {
+	v := visit{rv.UnsafePointer(), rv.Type(), 0}
+	if rv.Kind() == reflect.Slice {
+		v.len = rv.Len()
+	}
+	return v
+}
+
+func (c *converter) enter(rv reflect.Value) error {
+	v := visitOf(rv)
+	if c.path[v] {
+		return fmt.Errorf("cannot convert cyclic %s", rv.Type())
+	}
+	if c.path == nil {
+		c.path = make(map[visit]bool)
+	}
+	c.path[v] = true
+	return nil
+}
+
+func (c *converter) leave(rv reflect.Value) {
+	delete(c.path, visitOf(rv))
+}
+
+func (c *converter) value(rv reflect.Value) (Value, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	t := rv.Type()
+	switch {
+	case t == timeType:
+		return rv.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	case t == bigIntType || isScriptType(t):
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil, nil
+		}
+		return rv.Interface(), nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > uint64(^uint(0)>>1) {
+			return new(big.Int).SetUint64(u), nil
+		}
+		return int(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return c.value(rv.Elem())
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if err := c.enter(rv); err != nil {
+			return nil, err
+		}
+		defer c.leave(rv)
+		return c.value(rv.Elem())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return nil, nil
+			}
+			if err := c.enter(rv); err != nil {
+				return nil, err
+			}
+			defer c.leave(rv)
+		}
+		arr := make([]any, rv.Len())
+		for i := range arr {
+			v, err := c.value(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return eval.NewArray(arr), nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if err := c.enter(rv); err != nil {
+			return nil, err
+		}
+		defer c.leave(rv)
+		m := eval.NewMap()
+		for _, k := range sortedKeys(rv) {
+			key, err := c.value(k)
+			if err != nil {
+				return nil, err
+			}
+			v, err := c.value(rv.MapIndex(k))
+			if err != nil {
+				return nil, fmt.Errorf("key %v: %w", key, err)
+			}
+			m.Set(key, v)
+		}
+		return m, nil
+	case reflect.Struct:
+		m := eval.NewMap()
+		for _, f := range structFields(t) {
+			v, err := c.value(rv.FieldByIndex(f.index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			m.Set(f.name, v)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a script value", t)
+}
+
+// isScriptType reports whether t is one of the evaluator's runtime types,
+// which pass through conversion unchanged.
+func isScriptType(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.PkgPath() == evalPkgPath
+}
+
+// sortedKeys orders Go map keys so converted maps have a stable order.
+func sortedKeys(rv reflect.Value) []reflect.Value {
+	keys := rv.MapKeys()
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		switch {
+		case a.CanInt():
+			return cmp.Compare(a.Int(), b.Int())
+		case a.CanUint():
+			return cmp.Compare(a.Uint(), b.Uint())
+		case a.CanFloat():
+			return cmp.Compare(a.Float(), b.Float())
+		case a.Kind() == reflect.String:
+			return cmp.Compare(a.String(), b.String())
+		}
+		return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	return keys
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields lists the exported fields of t, including those promoted
+// from embedded structs, under their script names.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous || !f.IsExported() || !settablePath(t, f.Index) {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("gos"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, structField{name: name, index: f.Index})
+	}
+	return fields
+}
+
+// settablePath reports whether a promoted field can be reached without
+// following a pointer or passing through an unexported embedded struct.
+func settablePath(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		f := t.Field(i)
+		if f.Type.Kind() != reflect.Struct || !f.IsExported() {
+			return false
+		}
+		t = f.Type
+	}
+	return true
+}
+
+// fromValue converts a script value to a new Go value of type t.
+func fromValue(v Value, t reflect.Type) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	return out, assignValue(out, v)
+}
+
+func assignValue(dst reflect.Value, v Value) error {
+	t := dst.Type()
+	switch t {
+	case timeType:
+		var tm time.Time
+		switch x := v.(type) {
+		case string:
+			parsed, err := time.Parse(time.RFC3339Nano, x)
+			if err != nil {
+				return fmt.Errorf("cannot parse %q as an RFC 3339 time", x)
+			}
+			tm = parsed
+		case int:
+			tm = time.Unix(int64(x), 0).UTC()
+		default:
+			return mismatch(v, t)
+		}
+		dst.Set(reflect.ValueOf(tm))
+		return nil
+	case durationType:
+		if s, ok := v.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("cannot parse %q as a duration", s)
+			}
+			dst.SetInt(int64(d))
+			return nil
+		}
+	case bigIntType:
+		n, ok := scriptInt(v)
+		if !ok {
+			return mismatch(v, t)
+		}
+		dst.Set(reflect.ValueOf(new(big.Int).Set(n)))
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v == nil {
+			dst.Set(reflect.Zero(t))
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(t.Elem()))
+		}
+		return assignValue(dst.Elem(), v)
+	case reflect.Interface:
+		if v == nil {
+			dst.Set(reflect.Zero(t))
+			return nil
+		}
+		if t.NumMethod() == 0 {
+			gv, err := goValue(v, map[Value]bool{})
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(gv))
+			return nil
+		}
+		if rv := reflect.ValueOf(v); rv.Type().Implements(t) {
+			dst.Set(rv)
+			return nil
+		}
+		return mismatch(v, t)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := scriptInt(v)
+		if !ok {
+			return mismatch(v, t)
+		}
+		if !n.IsInt64() || dst.OverflowInt(n.Int64()) {
+			return fmt.Errorf("%s overflows %s", n, t)
+		}
+		dst.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := scriptInt(v)
+		if !ok {
+			return mismatch(v, t)
+		}
+		if !n.IsUint64() || dst.OverflowUint(n.Uint64()) {
+			return fmt.Errorf("%s overflows %s", n, t)
+		}
+		dst.SetUint(n.Uint64())
+	case reflect.Float32, reflect.Float64:
+		switch n := v.(type) {
+		case float64:
+			dst.SetFloat(n)
+		case int:
+			dst.SetFloat(float64(n))
+		case *big.Int:
+			f, _ := new(big.Float).SetInt(n).Float64()
+			dst.SetFloat(f)
+		case eval.Decimal:
+			dst.SetFloat(n.Float64())
+		default:
+			return mismatch(v, t)
+		}
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return mismatch(v, t)
+		}
+		dst.SetString(s)
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return mismatch(v, t)
+		}
+		dst.SetBool(b)
+	case reflect.Slice, reflect.Array:
+		if v == nil && t.Kind() == reflect.Slice {
+			dst.Set(reflect.Zero(t))
+			return nil
+		}
+		elems, ok := scriptElements(v)
+		if !ok {
+			return mismatch(v, t)
+		}
+		if t.Kind() == reflect.Array && len(elems) != t.Len() {
+			return fmt.Errorf("cannot use array of length %d as %s", len(elems), t)
+		}
+		if t.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(t, len(elems), len(elems)))
+		}
+		for i, el := range elems {
+			if err := assignValue(dst.Index(i), el); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	case reflect.Map:
+		if v == nil {
+			dst.Set(reflect.Zero(t))
+			return nil
+		}
+		m, ok := v.(*eval.Map)
+		if !ok {
+			return mismatch(v, t)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(t, m.Len()))
+		}
+		values := m.Values()
+		for i, k := range m.Keys() {
+			kv, err := fromValue(k, t.Key())
+			if err != nil {
+				return fmt.Errorf("key %v: %w", k, err)
+			}
+			vv, err := fromValue(values[i], t.Elem())
+			if err != nil {
+				return fmt.Errorf("key %v: %w", k, err)
+			}
+			dst.SetMapIndex(kv, vv)
+		}
+	case reflect.Struct:
+		field, ok := scriptFields(v)
+		if !ok {
+			return mismatch(v, t)
+		}
+		for _, f := range structFields(t) {
+			fv, ok := field(f.name)
+			if !ok {
+				continue
+			}
+			if err := assignValue(dst.FieldByIndex(f.index), fv); err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot convert to unsupported type %s", t)
+	}
+	return nil
+}
+
+// goValue converts a script value to plain Go values for an empty
+// interface. Non-string map keys are kept as script values, with tuples
+// rendered as strings since Go cannot hash them. path holds the script
+// containers being converted, so that one holding itself is reported
+// instead of recursing forever.
+func goValue(v Value, path map[Value]bool) (any, error) {
+	switch v.(type) {
+	case *eval.Array, *eval.Map, *eval.StructInstance:
+		if path[v] {
+			return nil, fmt.Errorf("cannot convert cyclic %s", eval.TypeName(v))
+		}
+		path[v] = true
+		defer delete(path, v)
+	}
+	switch t := v.(type) {
+	case *eval.Array:
+		return goElements(t.Elements, path)
+	case eval.Tuple:
+		return goElements(t, path)
+	case *eval.Map:
+		values := t.Values()
+		if allStrings(t.Keys()) {
+			ret := make(map[string]any, t.Len())
+			for i, k := range t.Keys() {
+				el, err := goValue(values[i], path)
+				if err != nil {
+					return nil, err
+				}
+				ret[k.(string)] = el
+			}
+			return ret, nil
+		}
+		ret := make(map[any]any, t.Len())
+		for i, k := range t.Keys() {
+			if tuple, ok := k.(eval.Tuple); ok {
+				k = tuple.String()
+			}
+			el, err := goValue(values[i], path)
+			if err != nil {
+				return nil, err
+			}
+			ret[k] = el
+		}
+		return ret, nil
+	case *eval.StructInstance:
+		ret := make(map[string]any, len(t.Values))
+		for i, name := range t.Type.Fields {
+			el, err := goValue(t.Values[i], path)
+			if err != nil {
+				return nil, err
+			}
+			ret[name] = el
+		}
+		return ret, nil
+	}
+	return v, nil
+}
+
+func goElements(elements []any, path map[Value]bool) ([]any, error) {
+	ret := make([]any, len(elements))
+	for i, el := range elements {
+		v, err := goValue(el, path)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func allStrings(values []any) bool {
+	for _, v := range values {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// scriptFields returns a field lookup for the struct-like script values:
+// maps with string keys, struct instances and class instances.
+func scriptFields(v Value) (func(name string) (Value, bool), bool) {
+	switch s := v.(type) {
+	case *eval.Map:
+		return func(name string) (Value, bool) {
+			return s.Get(name)
+		}, true
+	case *eval.StructInstance:
+		return func(name string) (Value, bool) {
+			i := slices.Index(s.Type.Fields, name)
+			if i < 0 {
+				return nil, false
+			}
+			return s.Values[i], true
+		}, true
+	case *eval.Object:
+		return func(name string) (Value, bool) {
+			v, ok := s.Fields[name]
+			return v, ok
+		}, true
+	}
+	return nil, false
+}
+
+func scriptInt(v Value) (*big.Int, bool) {
+	switch n := v.(type) {
+	case int:
+		return big.NewInt(int64(n)), true
+	case *big.Int:
+		return n, true
+	}
+	return nil, false
+}
+
+func scriptElements(v Value) ([]any, bool) {
+	switch t := v.(type) {
+	case *eval.Array:
+		return t.Elements, true
+	case eval.Tuple:
+		return t, true
+	}
+	return nil, false
+}
+
+func mismatch(v Value, t reflect.Type) error {
+	return fmt.Errorf("cannot use %s as %s", eval.TypeName(v), t)
+}
@@ -0,0 +1,31 @@
+package eval
+
+import "strings"
+
+// Array is a script array. Arrays are shared by reference: push, pop,
+// insert and remove change the array itself, so the change is seen through
+// every variable holding it.
+type Array struct {
+	Elements []any
+}
+
+func NewArray(elements []any) *Array {
+	if elements == nil {
+		elements = []any{}
+	}
+	return &Array{Elements: elements}
+}
+
+func (a *Array) String() string {
+	return a.format(visits{})
+}
+
+func (a *Array) format(seen visits) string {
+	seen.enter(a, a, "format")
+	defer seen.leave(a)
+	parts := make([]string, len(a.Elements))
+	for i, v := range a.Elements {
+		parts[i] = format(v, seen)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -0,0 +1,266 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Builtin struct {
+	Name string
+	Fn   func(args []any) any
+}
+
+var builtins map[string]Builtin
+
+// builtins is filled in init because the builtin functions call back into
+// the evaluator, which looks functions up in this map.
+func init() {
+	builtins = map[string]Builtin{
+		"bytes":     {Name: "bytes", Fn: builtinBytes},
+		"runes":     {Name: "runes", Fn: builtinRunes},
+		"graphemes": {Name: "graphemes", Fn: builtinGraphemes},
+		"iter":      {Name: "iter", Fn: builtinIter},
+		"sorted":    {Name: "sorted", Fn: builtinSorted},
+		"tuple":     {Name: "tuple", Fn: builtinTuple},
+		"freeze":    {Name: "freeze", Fn: builtinFreeze},
+		"int":       {Name: "int", Fn: builtinInt},
+		"float":     {Name: "float", Fn: builtinFloat},
+		"str":       {Name: "str", Fn: builtinStr},
+		"bool":      {Name: "bool", Fn: builtinBool},
+		"decimal":   {Name: "decimal", Fn: builtinDecimal},
+		"round":     {Name: "round", Fn: builtinRound},
+		"type":      {Name: "type", Fn: builtinType},
+	}
+}
+
+// NewBuiltin wraps a host function as a builtin. An error returned by fn,
+// or a panic in it, becomes a runtime error in the calling script, so a
+// faulty host function fails the script instead of crashing the host.
+func NewBuiltin(name string, fn func(args []any) (any, error)) Builtin {
+	return Builtin{Name: name, Fn: func(args []any) any {
+		v, err := callHost(fn, args)
+		if err != nil {
+			raiseError("%s: %v", name, err)
+		}
+		return v
+	}}
+}
+
+func callHost(fn func(args []any) (any, error), args []any) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(args)
+}
+
+func checkArgCount(name string, args []any, want int) {
+	if len(args) != want {
+		raiseError("%s expects %d arguments, got %d", name, want, len(args))
+	}
+}
+
+func stringArg(name string, args []any, i int) string {
+	s, ok := args[i].(string)
+	if !ok {
+		raiseError("%s expects a string argument, got %s", name, TypeName(args[i]))
+	}
+	return s
+}
+
+func intArg(name string, args []any, i int) int {
+	n, ok := args[i].(int)
+	if !ok {
+		raiseError("%s expects an int argument, got %s", name, TypeName(args[i]))
+	}
+	return n
+}
+
+func builtinInt(args []any) any {
+	checkArgCount("int", args, 1)
+	switch v := args[0].(type) {
+	case int, *big.Int:
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			raiseError("cannot convert %v to int", v)
+		}
+		if v >= -(1<<63) && v < 1<<63 {
+			return int(v)
+		}
+		n, _ := big.NewFloat(v).Int(nil)
+		return normalizeBigInt(n)
+	case Decimal:
+		return normalizeBigInt(v.Round(0, RoundDown).Unscaled)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case string:
+		n, ok := new(big.Int).SetString(strings.TrimSpace(v), 10)
+		if !ok {
+			raiseError("cannot convert %q to int", v)
+		}
+		return normalizeBigInt(n)
+	}
+	raiseError("cannot convert %s to int", TypeName(args[0]))
+	return nil
+}
+
+func builtinFloat(args []any) any {
+	checkArgCount("float", args, 1)
+	switch v := args[0].(type) {
+	case int, *big.Int:
+		return promoteNumber(v, rankFloat)
+	case float64:
+		return v
+	case Decimal:
+		return v.Float64()
+	case bool:
+		if v {
+			return 1.0
+		}
+		return 0.0
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			raiseError("cannot convert %q to float", v)
+		}
+		return f
+	}
+	raiseError("cannot convert %s to float", TypeName(args[0]))
+	return nil
+}
+
+func builtinStr(args []any) any {
+	checkArgCount("str", args, 1)
+	if s, ok := args[0].(string); ok {
+		return s
+	}
+	return formatValue(args[0])
+}
+
+func builtinBool(args []any) any {
+	checkArgCount("bool", args, 1)
+	switch v := args[0].(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case *big.Int:
+		return v.Sign() != 0
+	case float64:
+		return v != 0
+	case Decimal:
+		return v.Unscaled.Sign() != 0
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			raiseError("cannot convert %q to bool", v)
+		}
+		return b
+	}
+	raiseError("cannot convert %s to bool", TypeName(args[0]))
+	return nil
+}
+
+func builtinBytes(args []any) any {
+	checkArgCount("bytes", args, 1)
+	s := stringArg("bytes", args, 0)
+	ret := make([]any, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		ret = append(ret, int(s[i]))
+	}
+	return NewArray(ret)
+}
+
+func builtinRunes(args []any) any {
+	checkArgCount("runes", args, 1)
+	s := stringArg("runes", args, 0)
+	ret := make([]any, 0, utf8.RuneCountInString(s))
+	for _, r := range s {
+		ret = append(ret, int(r))
+	}
+	return NewArray(ret)
+}
+
+func builtinGraphemes(args []any) any {
+	checkArgCount("graphemes", args, 1)
+	ret := []any{}
+	for _, g := range splitGraphemes(stringArg("graphemes", args, 0)) {
+		ret = append(ret, g)
+	}
+	return NewArray(ret)
+}
+
+func builtinSorted(args []any) any {
+	checkArgCount("sorted", args, 1)
+	switch t := args[0].(type) {
+	case *Map:
+		return t.Sorted()
+	case *Array:
+		ret := append([]any{}, t.Elements...)
+		sort.SliceStable(ret, func(i, j int) bool {
+			return compareValues(ret[i], ret[j]) < 0
+		})
+		return NewArray(ret)
+	}
+	raiseError("sorted expects an array or map, got %s", TypeName(args[0]))
+	return nil
+}
+
+// splitGraphemes approximates extended grapheme clusters: combining marks,
+// emoji modifiers, zero-width joiner sequences, regional indicator pairs and
+// CRLF stay attached to the preceding character.
+func splitGraphemes(s string) []string {
+	clusters := []string{}
+	start := 0
+	var prev rune = -1
+	regionalRun := 0
+	for i, r := range s {
+		if i > 0 && !continuesCluster(prev, r, regionalRun) {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+		if isRegionalIndicator(r) {
+			regionalRun++
+		} else {
+			regionalRun = 0
+		}
+		prev = r
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+func continuesCluster(prev, r rune, regionalRun int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return true
+	case prev == '\u200d' || r == '\u200d':
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		return true
+	case isRegionalIndicator(prev) && isRegionalIndicator(r):
+		return regionalRun%2 == 1
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,159 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/basemax/goscript/ast"
+)
+
+type Class struct {
+	Name    string
+	Parent  *Class
+	Methods map[string]Function
+}
+
+type Object struct {
+	Class  *Class
+	Fields map[string]any
+	order  []string
+}
+
+type BoundMethod struct {
+	Self   *Object
+	Owner  *Class
+	Method Function
+}
+
+type superRef struct {
+	Self  *Object
+	Class *Class
+}
+
+func evalClassDecl(n ast.ClassDecl, env *Environment) any {
+	class := &Class{Name: n.Name, Methods: map[string]Function{}}
+	if n.Parent != nil {
+		parent, _ := env.GetFunction(n.Parent.Lexeme.Text)
+		p, ok := parent.(*Class)
+		if !ok {
+			raiseError("class %s cannot inherit from %s", n.Name, n.Parent.Lexeme.Text)
+		}
+		class.Parent = p
+	}
+	for _, method := range n.Methods {
+		if len(method.Params) == 0 {
+			raiseError("method %s.%s must take self as its first parameter", n.Name, method.Name)
+		}
+		class.Methods[method.Name] = Function{FunctionLiteral: method, Env: env}
+	}
+	env.SetFunction(n.Name, class)
+	return nil
+}
+
+func (c *Class) findMethod(name string) (Function, *Class, bool) {
+	for cls := c; cls != nil; cls = cls.Parent {
+		if m, ok := cls.Methods[name]; ok {
+			return m, cls, true
+		}
+	}
+	return Function{}, nil, false
+}
+
+func (c *Class) inherits(other *Class) bool {
+	for cls := c; cls != nil; cls = cls.Parent {
+		if cls == other {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Class) instantiate(args []any) *Object {
+	obj := &Object{Class: c, Fields: map[string]any{}}
+	if init, owner, ok := c.findMethod("init"); ok {
+		BoundMethod{Self: obj, Owner: owner, Method: init}.call(args)
+	} else if len(args) > 0 {
+		raiseError("%s takes no arguments", c.Name)
+	}
+	return obj
+}
+
+func (c *Class) String() string {
+	return "class " + c.Name
+}
+
+func (o *Object) method(name string) (BoundMethod, bool) {
+	m, owner, ok := o.Class.findMethod(name)
+	return BoundMethod{Self: o, Owner: owner, Method: m}, ok
+}
+
+func (o *Object) get(name string) any {
+	if v, ok := o.Fields[name]; ok {
+		return v
+	}
+	if m, ok := o.method(name); ok {
+		return m
+	}
+	raiseError("%s has no field or method %s", o.Class.Name, name)
+	return nil
+}
+
+func (o *Object) set(name string, v any) {
+	if _, ok := o.Fields[name]; !ok {
+		o.order = append(o.order, name)
+	}
+	o.Fields[name] = v
+}
+
+func (o *Object) String() string {
+	return o.format(visits{})
+}
+
+func (o *Object) format(seen visits) string {
+	if m, ok := o.method("__str__"); ok {
+		s, isString := m.call(nil).(string)
+		if !isString {
+			raiseError("%s.__str__ must return a string", o.Class.Name)
+		}
+		return s
+	}
+	seen.enter(o, o, "format")
+	defer seen.leave(o)
+	parts := make([]string, len(o.order))
+	for i, name := range o.order {
+		parts[i] = name + ": " + format(o.Fields[name], seen)
+	}
+	return o.Class.Name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (m BoundMethod) call(args []any) any {
+	if m.Method.IsGenerator {
+		return newGenerator(m.Method, func() *Environment {
+			return m.environment(args)
+		})
+	}
+	m.Method.Env.checkCancelled()
+	m.Method.Env.state.enter()
+	defer m.Method.Env.state.leave()
+	env := m.environment(args)
+	return checkReturn(m.Method, unwrapReturn(Eval(m.Method.Body, env)))
+}
+
+func (m BoundMethod) environment(args []any) *Environment {
+	env := argsToEnvironment(m.Method, append([]any{m.Self}, args...), true)
+	if m.Owner.Parent != nil {
+		env.variables["super"] = superRef{Self: m.Self, Class: m.Owner.Parent}
+	}
+	return env
+}
+
+func (m BoundMethod) String() string {
+	return "<method " + m.Owner.Name + "." + m.Method.Name + ">"
+}
+
+func (s superRef) bind(name string) BoundMethod {
+	m, owner, ok := s.Class.findMethod(name)
+	if !ok {
+		raiseError("%s has no method %s", s.Class.Name, name)
+	}
+	return BoundMethod{Self: s.Self, Owner: owner, Method: m}
+}
@@ -0,0 +1,126 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/basemax/goscript/ast"
+)
+
+type Enum struct {
+	Name     string
+	Variants []*EnumVariant
+}
+
+type EnumVariant struct {
+	Enum   *Enum
+	Name   string
+	Fields []string
+	unit   *EnumValue
+}
+
+type EnumValue struct {
+	Variant *EnumVariant
+	Values  []any
+}
+
+func evalEnumDecl(n ast.EnumDecl, env *Environment) any {
+	enum := &Enum{Name: n.Name}
+	for _, decl := range n.Variants {
+		if _, ok := enum.variant(decl.Name); ok {
+			raiseError("enum %s declares variant %s more than once", n.Name, decl.Name)
+		}
+		variant := &EnumVariant{Enum: enum, Name: decl.Name, Fields: decl.Fields}
+		if len(decl.Fields) == 0 {
+			variant.unit = &EnumValue{Variant: variant}
+		}
+		enum.Variants = append(enum.Variants, variant)
+	}
+	env.SetFunction(n.Name, enum)
+	return nil
+}
+
+func (e *Enum) variant(name string) (*EnumVariant, bool) {
+	for _, v := range e.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (e *Enum) member(name string) any {
+	v, ok := e.variant(name)
+	if !ok {
+		raiseError("enum %s has no variant %s", e.Name, name)
+	}
+	if v.unit != nil {
+		return v.unit
+	}
+	return v
+}
+
+func (e *Enum) members() []any {
+	ret := make([]any, len(e.Variants))
+	for i, v := range e.Variants {
+		ret[i] = e.member(v.Name)
+	}
+	return ret
+}
+
+func (e *Enum) String() string {
+	return "enum " + e.Name
+}
+
+func (v *EnumVariant) construct(args []any) *EnumValue {
+	if v.unit != nil {
+		raiseError("%s.%s takes no arguments", v.Enum.Name, v.Name)
+	}
+	values, missing := bindArguments(v.Enum.Name+"."+v.Name, "field", v.Fields, args)
+	if len(missing) > 0 {
+		raiseError("%s.%s is missing %s", v.Enum.Name, v.Name, strings.Join(missing, ", "))
+	}
+	return &EnumValue{Variant: v, Values: values}
+}
+
+func (v *EnumVariant) String() string {
+	return v.Enum.Name + "." + v.Name
+}
+
+func (v *EnumValue) get(name string) any {
+	i := indexOfString(v.Variant.Fields, name)
+	if i < 0 {
+		raiseError("%s has no field %s", v.Variant, name)
+	}
+	return v.Values[i]
+}
+
+func (v *EnumValue) equal(other *EnumValue, seen visits) bool {
+	if v.Variant != other.Variant {
+		return false
+	}
+	for i, val := range v.Values {
+		if !equal(val, other.Values[i], seen) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *EnumValue) String() string {
+	return v.format(visits{})
+}
+
+func (v *EnumValue) format(seen visits) string {
+	if v.unit() {
+		return v.Variant.String()
+	}
+	parts := make([]string, len(v.Values))
+	for i, val := range v.Values {
+		parts[i] = v.Variant.Fields[i] + ": " + format(val, seen)
+	}
+	return v.Variant.String() + "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (v *EnumValue) unit() bool {
+	return v.Variant.unit == v
+}
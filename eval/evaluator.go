@@ -0,0 +1,1193 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/basemax/goscript/ast"
+	"github.com/basemax/goscript/parser"
+)
+
+type RuntimeError struct {
+	Message string
+}
+
+func (e *RuntimeError) Error() string {
+	return "runtime error: " + e.Message
+}
+
+func raiseError(format string, args ...any) {
+	panic(&RuntimeError{Message: fmt.Sprintf(format, args...)})
+}
+
+// TypeName returns the canonical script name of v's type, as reported by
+// the type builtin.
+func TypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case int, *big.Int:
+		return "int"
+	case float64:
+		return "float"
+	case Decimal:
+		return "decimal"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *Array:
+		return "array"
+	case Tuple:
+		return "tuple"
+	case *Map:
+		return "map"
+	case Function, Builtin, BoundMethod:
+		return "function"
+	case *StructType, *Class:
+		return "type"
+	case *StructInstance:
+		return v.(*StructInstance).Type.Name
+	case *Object:
+		return v.(*Object).Class.Name
+	case *Enum:
+		return "type"
+	case *EnumVariant:
+		return "function"
+	case *EnumValue:
+		return v.(*EnumValue).Variant.Enum.Name
+	case *Generator:
+		return "generator"
+	case *IteratorValue:
+		return "iterator"
+	case stopValue:
+		return "stop"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// visits holds the mutable containers on the path from the value being
+// formatted or compared down to the current element. Only mutable
+// containers can close a cycle, so meeting one again on the same path means
+// the value contains itself; that raises an error rather than recursing
+// until the Go stack overflows.
+type visits map[any]bool
+
+func (s visits) enter(key, v any, verb string) {
+	if s[key] {
+		raiseError("cannot %s cyclic %s", verb, TypeName(v))
+	}
+	s[key] = true
+}
+
+func (s visits) leave(key any) {
+	delete(s, key)
+}
+
+// formatValue renders v as print shows it. String methods are called
+// directly rather than through fmt, which would swallow a panic raised by a
+// class's __str__, including limit and cancellation errors.
+func formatValue(v any) string {
+	return format(v, visits{})
+}
+
+func format(v any, seen visits) string {
+	switch t := v.(type) {
+	case *Array:
+		return t.format(seen)
+	case Tuple:
+		return t.format(seen)
+	case *Map:
+		return t.format(seen)
+	case *StructInstance:
+		return t.format(seen)
+	case *EnumValue:
+		return t.format(seen)
+	case *Object:
+		return t.format(seen)
+	case fmt.Stringer:
+		return t.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func valuesEqual(a, b any) bool {
+	return equal(a, b, visits{})
+}
+
+// equal compares a and b structurally. seen is keyed by the pair being
+// compared, so a cycle is only reported when the comparison itself would
+// never end.
+func equal(a, b any, seen visits) bool {
+	switch l := a.(type) {
+	case *StructInstance:
+		r, ok := b.(*StructInstance)
+		return ok && l.equal(r, seen)
+	case *EnumValue:
+		r, ok := b.(*EnumValue)
+		return ok && l.equal(r, seen)
+	case *Object:
+		if m, ok := l.method("__eq__"); ok {
+			return boolResult("__eq__", m.call([]any{b}))
+		}
+		return a == b
+	case *Array:
+		switch r := b.(type) {
+		case *Array:
+			if l == r {
+				return true
+			}
+			key := [2]any{l, r}
+			seen.enter(key, l, "compare")
+			defer seen.leave(key)
+			return sequencesEqual(l.Elements, r.Elements, seen)
+		case Tuple:
+			seen.enter(l, l, "compare")
+			defer seen.leave(l)
+			return sequencesEqual(l.Elements, r, seen)
+		}
+		return false
+	case Tuple:
+		switch r := b.(type) {
+		case *Array:
+			seen.enter(r, r, "compare")
+			defer seen.leave(r)
+			return sequencesEqual(l, r.Elements, seen)
+		case Tuple:
+			return sequencesEqual(l, r, seen)
+		}
+		return false
+	case *Map:
+		r, ok := b.(*Map)
+		return ok && l.equal(r, seen)
+	case *Class, *StructType, *Enum, *EnumVariant:
+		return a == b
+	case nil, string, bool, stopValue:
+		return a == b
+	}
+	if l, r, ok := coerceNumbers(a, b); ok {
+		return compareNumbers(l, r) == 0
+	}
+	return false
+}
+
+func sequencesEqual(l, r []any, seen visits) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	for i, v := range l {
+		if !equal(v, r[i], seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Eval evaluates a single syntax tree node in env, counting it against the
+// step limit.
+func Eval(node ast.Node, env *Environment) any {
+	env.state.step()
+	return evalNode(node, env)
+}
+
+func evalNode(node ast.Node, env *Environment) any {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case ast.Ident:
+		return evalIdent(n, env)
+	case ast.IntegerLiteral:
+		return evalIntegerLiteral(n, env)
+	case ast.FloatLiteral:
+		return evalFloatLiteral(n, env)
+	case ast.DecimalLiteral:
+		return evalDecimalLiteral(n, env)
+	case ast.StringLiteral:
+		return evalStringLiteral(n, env)
+	case ast.BooleanLiteral:
+		return evalBooleanLiteral(n, env)
+	case ast.ArrayLiteral:
+		return evalArrayLiteral(n, env)
+	case ast.TupleLiteral:
+		return evalTupleLiteral(n, env)
+	case ast.MapLiteral:
+		return evalMapLiteral(n, env)
+	case ast.PrefixOp:
+		return evalPrefixOp(n, env)
+	case ast.InfixOp:
+		return evalInfixOp(n, env)
+	case ast.IsExpr:
+		return evalIsExpr(n, env)
+	case ast.VarAssign:
+		return evalVarAssign(n, env)
+	case ast.LetStmt:
+		return evalLetStmt(n, env)
+	case ast.BlockStmt:
+		return evalBlockStmt(n, env)
+	case *ast.BlockStmt:
+		return evalBlockStmt(*n, env)
+	case ast.IfStmt:
+		return evalIfStmt(n, env)
+	case ast.ForStmt:
+		return evalForStmt(n, env)
+	case ast.RangeExpr:
+		return evalRangeExpr(n, env)
+	case ast.BreakStmt:
+		return evalBreakStmt(n, env)
+	case ast.ReturnStmt:
+		return evalReturnStmt(n, env)
+	case ast.PrintStmt:
+		return evalPrintStmt(n, env)
+	case ast.IndexExpr:
+		return evalIndexExpr(n, env)
+	case ast.SliceExpr:
+		return evalSliceExpr(n, env)
+	case ast.MemberExpr:
+		return evalMemberExpr(n, env)
+	case ast.MethodCall:
+		return evalMethodCall(n, env)
+	case ast.CallExpr:
+		return evalCallExpr(n, env)
+	case ast.NamedArg:
+		return evalNamedArg(n, env)
+	case ast.FunctionLiteral:
+		return evalFunctionLiteral(n, env)
+	case ast.YieldStmt:
+		return evalYieldStmt(n, env)
+	case ast.StructDecl:
+		return evalStructDecl(n, env)
+	case ast.ClassDecl:
+		return evalClassDecl(n, env)
+	case ast.EnumDecl:
+		return evalEnumDecl(n, env)
+	case ast.MatchExpr:
+		return evalMatchExpr(n, env)
+	case ast.SwapStmt:
+		return evalSwapStmt(n, env)
+	case ast.ImportStmt:
+		return evalImportStmt(n, env)
+	case ast.InputStmt:
+		return evalInputStmt(n, env)
+	case ast.LengthExpr:
+		return evalLengthExpr(n, env)
+	}
+	raiseError("cannot evaluate %T", node)
+	return nil
+}
+
+type Environment struct {
+	variables map[string]any
+	functions map[string]any
+	parent    *Environment
+	generator *Generator
+	state     *runState
+}
+
+func CreateEnvironment(parent *Environment) *Environment {
+	env := &Environment{
+		variables: make(map[string]any),
+		functions: make(map[string]any),
+		parent:    parent,
+	}
+	if parent != nil {
+		env.state = parent.state
+	} else {
+		env.state = newRunState()
+	}
+	return env
+}
+
+// Define binds name to v in env itself, shadowing any outer binding.
+func (env *Environment) Define(name string, v any) {
+	env.variables[name] = v
+}
+
+// Lookup resolves name the way an identifier in a script does: variables
+// first, then functions and types, then builtins.
+func (env *Environment) Lookup(name string) (any, bool) {
+	if v, ok := env.GetVariable(name); ok {
+		return v, true
+	}
+	return env.GetFunction(name)
+}
+
+func (env *Environment) SetVariable(k ast.Node, v any) {
+	switch node := k.(type) {
+	case ast.Ident:
+		env.variables[node.Lexeme.Text] = v
+	case ast.IndexExpr:
+		container := Eval(node.Collection, env)
+		m, isMap := container.(*Map)
+		before := 0
+		if isMap {
+			before = m.Len()
+		}
+		assignIndex(container, Eval(node.Index, env), v)
+		if isMap && m.Len() > before {
+			env.state.checkSize(m)
+			env.state.charge(64)
+		}
+	case ast.MemberExpr:
+		assignMember(Eval(node.Object, env), node.Name, v)
+	default:
+		raiseError("invalid assignment target")
+	}
+}
+
+func assignMember(container any, name string, v any) {
+	switch t := container.(type) {
+	case *Map:
+		t.Set(name, v)
+	case *StructInstance:
+		t.set(name, v)
+	case *Object:
+		t.set(name, v)
+	default:
+		raiseError("cannot set field %s on %s", name, TypeName(container))
+	}
+}
+
+func assignIndex(container, index, v any) {
+	switch t := container.(type) {
+	case *Array:
+		t.Elements[normalizeIndex(index, len(t.Elements))] = v
+	case *Map:
+		t.Set(index, v)
+	case *Object:
+		m, ok := t.method("__setindex__")
+		if !ok {
+			raiseError("cannot assign into %s", TypeName(container))
+		}
+		m.call([]any{index, v})
+	default:
+		raiseError("cannot assign into %s", TypeName(container))
+	}
+}
+
+func (env *Environment) GetVariable(s string) (any, bool) {
+	v, ok := env.variables[s]
+	if !ok && env.parent != nil {
+		return env.parent.GetVariable(s)
+	}
+	return v, ok
+}
+
+func (env *Environment) SetFunction(s string, v any) {
+	env.functions[s] = v
+}
+
+func (env *Environment) GetFunction(s string) (any, bool) {
+	v, ok := env.functions[s]
+	if !ok && env.parent != nil {
+		return env.parent.GetFunction(s)
+	}
+	if !ok {
+		if b, found := builtins[s]; found {
+			return b, true
+		}
+		if h, found := hostBuiltins[s]; found {
+			return Builtin{Name: s, Fn: func(args []any) any { return h(env.state, args) }}, true
+		}
+		if s == "stop" {
+			return Stop, true
+		}
+	}
+	return v, ok
+}
+
+func evalStringLiteral(n ast.StringLiteral, env *Environment) any {
+	return n.Value
+}
+
+func evalBooleanLiteral(n ast.BooleanLiteral, env *Environment) any {
+	return n.Value
+}
+
+func evalIntegerLiteral(n ast.IntegerLiteral, env *Environment) any {
+	if n.Big != nil {
+		return n.Big
+	}
+	return n.Value
+}
+
+func evalDecimalLiteral(n ast.DecimalLiteral, env *Environment) any {
+	d, ok := parseDecimal(n.Lexeme.Text)
+	if !ok {
+		raiseError("malformed decimal %sd", n.Lexeme.Text)
+	}
+	return d
+}
+
+func evalFloatLiteral(n ast.FloatLiteral, env *Environment) any {
+	return n.Value
+}
+
+type returnSignal struct {
+	value any
+}
+
+func evalReturnStmt(n ast.ReturnStmt, env *Environment) any {
+	if n.Expr == nil {
+		return returnSignal{}
+	}
+	return returnSignal{value: Eval(n.Expr, env)}
+}
+
+func unwrapReturn(result any) any {
+	switch r := result.(type) {
+	case returnSignal:
+		return r.value
+	case breakSignal:
+		raiseError("break outside of a loop")
+	}
+	return result
+}
+
+func evalIdent(n ast.Ident, env *Environment) any {
+	name := n.Lexeme.Text
+	if n.IsFunc {
+		if v, ok := env.GetFunction(name); ok {
+			return v
+		}
+		v, _ := env.GetVariable(name)
+		return v
+	}
+	if v, ok := env.GetVariable(name); ok {
+		return v
+	}
+	v, _ := env.GetFunction(name)
+	return v
+}
+
+func evalVarAssign(n ast.VarAssign, env *Environment) any {
+	v := Eval(n.Value, env)
+	env.SetVariable(n.Name, v)
+	return v
+}
+
+func evalPrefixOp(n ast.PrefixOp, env *Environment) any {
+	v := Eval(n.Expr, env)
+	return evalPrefix(n.Lexeme.Text, v)
+}
+
+func evalPrefix(prefix string, v any) any {
+	switch v := v.(type) {
+	case bool:
+		if prefix == "!" {
+			return !v
+		}
+	case int:
+		if prefix == "-" {
+			return negateInt(v)
+		}
+	case *big.Int:
+		if prefix == "-" {
+			return normalizeBigInt(new(big.Int).Neg(v))
+		}
+	case Decimal:
+		if prefix == "-" {
+			return v.Neg()
+		}
+	case float64:
+		if prefix == "-" {
+			return v * -1
+		}
+	case *Object:
+		if m, ok := v.method("__neg__"); ok && prefix == "-" {
+			return m.call(nil)
+		}
+	}
+	raiseError("unsupported operator %s for %s", prefix, TypeName(v))
+	return nil
+}
+
+func evalInfixOp(n ast.InfixOp, env *Environment) any {
+	operator := n.Lexeme.Text
+	if operator == "and" || operator == "or" {
+		return evalLogical(n, env)
+	}
+	l := Eval(n.Left, env)
+	r := Eval(n.Right, env)
+	if obj, ok := l.(*Object); ok {
+		return evalObjectInfix(obj, r, operator)
+	}
+	v := evalBinary(l, r, operator)
+	if s, ok := v.(string); ok && operator == "+" {
+		env.state.alloc(s)
+	}
+	return v
+}
+
+func evalLogical(n ast.InfixOp, env *Environment) any {
+	l := logicalOperand(Eval(n.Left, env), n.Lexeme.Text)
+	if (n.Lexeme.Text == "and" && !l) || (n.Lexeme.Text == "or" && l) {
+		return l
+	}
+	return logicalOperand(Eval(n.Right, env), n.Lexeme.Text)
+}
+
+func logicalOperand(v any, operator string) bool {
+	b, ok := v.(bool)
+	if !ok {
+		raiseError("operator %s expects bool operands, got %s", operator, TypeName(v))
+	}
+	return b
+}
+
+// evalBinary applies the coercion rules shared by every binary operator:
+// equality works across all types, numbers are promoted to a common type,
+// strings compare lexicographically and concatenate with numbers and bools,
+// and every other combination is an error. Strings are never parsed as
+// numbers implicitly; use int() or float() for that.
+func evalBinary(l, r any, operator string) any {
+	switch operator {
+	case "==":
+		return valuesEqual(l, r)
+	case "!=":
+		return !valuesEqual(l, r)
+	}
+	if ln, rn, ok := coerceNumbers(l, r); ok {
+		switch ln.(type) {
+		case int:
+			return evalIntInt(ln, rn, operator)
+		case *big.Int:
+			return evalBigBig(ln, rn, operator)
+		case float64:
+			return evalFloatFloat(ln, rn, operator)
+		case Decimal:
+			return evalDecimalDecimal(ln, rn, operator)
+		}
+	}
+	ls, lok := l.(string)
+	rs, rok := r.(string)
+	switch {
+	case lok && rok:
+		if v := evalStringString(ls, rs, operator); v != nil {
+			return v
+		}
+	case operator == "+" && lok && isConcatenable(r):
+		return ls + formatValue(r)
+	case operator == "+" && rok && isConcatenable(l):
+		return formatValue(l) + rs
+	}
+	raiseError("unsupported operator %s for %s and %s", operator, TypeName(l), TypeName(r))
+	return nil
+}
+
+func isConcatenable(v any) bool {
+	switch v.(type) {
+	case int, *big.Int, float64, Decimal, bool:
+		return true
+	}
+	return false
+}
+
+func evalIntInt(ll, rr any, operator string) any {
+	l := ll.(int)
+	r := rr.(int)
+	switch operator {
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case "+":
+		return addInts(l, r)
+	case "-":
+		return subInts(l, r)
+	case "*":
+		return mulInts(l, r)
+	case "/":
+		if r == 0 {
+			raiseError("integer division by zero")
+		}
+		if r == -1 {
+			return negateInt(l)
+		}
+		return l / r
+	}
+	raiseError("unsupported operator %s for int and int", operator)
+	return nil
+}
+
+func evalFloatFloat(ll, rr any, operator string) any {
+	l := ll.(float64)
+	r := rr.(float64)
+	switch operator {
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		return l / r
+	}
+	raiseError("unsupported operator %s for float and float", operator)
+	return nil
+}
+
+func evalStringString(l, r string, operator string) any {
+	switch operator {
+	case "+":
+		return l + r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	}
+	return nil
+}
+
+func evalBlockStmt(n ast.BlockStmt, env *Environment) any {
+	var result any
+	for _, stm := range n.Stmts {
+		if stm == nil {
+			continue
+		}
+		result = Eval(stm, env)
+		switch result.(type) {
+		case breakSignal, returnSignal:
+			return result
+		}
+		if result != nil {
+			switch stm.(type) {
+			case ast.IfStmt, ast.MatchExpr:
+				return result
+			}
+		}
+	}
+	return result
+}
+
+func evalIfStmt(n ast.IfStmt, env *Environment) any {
+	v := Eval(n.Condition, env)
+	condition, ok := v.(bool)
+	if !ok {
+		raiseError("if condition must be a bool, got %s", TypeName(v))
+	}
+	if condition {
+		return Eval(n.Then, env)
+	} else if n.Else != nil {
+		return Eval(n.Else, env)
+	}
+	return nil
+}
+
+func evalArrayLiteral(n ast.ArrayLiteral, env *Environment) any {
+	return NewArray(evalElements(n.Elements, env))
+}
+
+func evalTupleLiteral(n ast.TupleLiteral, env *Environment) any {
+	return Tuple(evalElements(n.Elements, env))
+}
+
+func evalElements(nodes []ast.Node, env *Environment) []any {
+	ret := []any{}
+	for _, node := range nodes {
+		ret = append(ret, Eval(node, env))
+	}
+	env.state.alloc(ret)
+	return ret
+}
+
+func evalMapLiteral(n ast.MapLiteral, env *Environment) any {
+	m := NewMap()
+	for _, pair := range n.Pairs {
+		m.Set(Eval(pair.Key, env), Eval(pair.Value, env))
+	}
+	env.state.alloc(m)
+	return m
+}
+
+func evalIndexExpr(n ast.IndexExpr, env *Environment) any {
+	index := Eval(n.Index, env)
+	switch t := Eval(n.Collection, env).(type) {
+	case *Map:
+		v, _ := t.Get(index)
+		return v
+	case *Array:
+		return t.Elements[normalizeIndex(index, len(t.Elements))]
+	case Tuple:
+		return t[normalizeIndex(index, len(t))]
+	case string:
+		runes := []rune(t)
+		return string(runes[normalizeIndex(index, len(runes))])
+	case *Object:
+		if m, ok := t.method("__index__"); ok {
+			return m.call([]any{index})
+		}
+		raiseError("cannot index %s", TypeName(t))
+	default:
+		raiseError("cannot index %s", TypeName(t))
+	}
+	return nil
+}
+
+func normalizeIndex(index any, length int) int {
+	i, ok := index.(int)
+	if !ok {
+		raiseError("index must be an integer, got %s", TypeName(index))
+	}
+	if i < 0 {
+		i += length
+	}
+	if i < 0 || i >= length {
+		raiseError("index %d out of range [0:%d]", index, length)
+	}
+	return i
+}
+
+func evalSliceExpr(n ast.SliceExpr, env *Environment) any {
+	target := Eval(n.Collection, env)
+	var length int
+	_, isTuple := target.(Tuple)
+	switch t := target.(type) {
+	case *Array:
+		target = t.Elements
+		length = len(t.Elements)
+	case Tuple:
+		target = []any(t)
+		length = len(t)
+	case string:
+		target = []rune(t)
+		length = len(target.([]rune))
+	default:
+		raiseError("cannot slice %s", TypeName(t))
+	}
+	start, end, step := sliceBounds(n, env, length)
+	switch t := target.(type) {
+	case []any:
+		ret := []any{}
+		for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+			ret = append(ret, t[i])
+		}
+		env.state.alloc(ret)
+		if isTuple {
+			return Tuple(ret)
+		}
+		return NewArray(ret)
+	default:
+		runes := t.([]rune)
+		if step == 1 {
+			return string(runes[start:end])
+		}
+		var builder strings.Builder
+		for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+			builder.WriteRune(runes[i])
+		}
+		return builder.String()
+	}
+}
+
+func sliceBounds(n ast.SliceExpr, env *Environment, length int) (int, int, int) {
+	step := 1
+	if n.Step != nil {
+		step = sliceBound(Eval(n.Step, env), "step")
+		if step == 0 {
+			raiseError("slice step cannot be zero")
+		}
+	}
+	start, end := 0, length
+	if step < 0 {
+		start, end = length-1, -1
+	}
+	if n.Start != nil {
+		start = sliceBound(Eval(n.Start, env), "start")
+		if start < 0 {
+			start += length
+		}
+	}
+	if n.End != nil {
+		end = sliceBound(Eval(n.End, env), "end")
+		if end < 0 {
+			end += length
+		}
+	}
+	if step > 0 && (start < 0 || end > length || start > end) {
+		raiseError("slice bounds [%d:%d] out of range for length %d", start, end, length)
+	}
+	if step < 0 && (start >= length || end < -1 || start < end) {
+		raiseError("slice bounds [%d:%d] out of range for length %d", start, end, length)
+	}
+	return start, end, step
+}
+
+func sliceBound(v any, name string) int {
+	i, ok := v.(int)
+	if !ok {
+		raiseError("slice %s must be an integer, got %s", name, TypeName(v))
+	}
+	return i
+}
+
+func evalPrintStmt(n ast.PrintStmt, env *Environment) any {
+	args := evalExpressions(n.Args, env)
+	var b strings.Builder
+	for i, v := range args {
+		// Space the values the way fmt.Print and fmt.Println do.
+		if i > 0 && (n.NewLine || !isString(args[i-1]) && !isString(v)) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(formatValue(v))
+	}
+	if n.NewLine {
+		b.WriteByte('\n')
+	}
+	fmt.Fprint(env.state.stdout, b.String())
+	return nil
+}
+
+func isString(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func evalExpressions(exps []ast.Node, env *Environment) []any {
+	res := []any{}
+	for _, exp := range exps {
+		r := Eval(exp, env)
+		res = append(res, r)
+	}
+	return res
+}
+
+// Function is a function literal closed over the environment it was
+// declared in.
+type Function struct {
+	ast.FunctionLiteral
+	Env *Environment
+}
+
+func evalFunctionLiteral(n ast.FunctionLiteral, env *Environment) any {
+	fn := Function{FunctionLiteral: n, Env: env}
+	env.SetFunction(n.Name, fn)
+	return fn
+}
+
+func evalCallExpr(n ast.CallExpr, env *Environment) any {
+	callee := Eval(n.Function, env)
+	if callee == nil {
+		if ident, ok := n.Function.(ast.Ident); ok {
+			raiseError("undefined function %s", ident.Lexeme.Text)
+		}
+	}
+	args := evalExpressions(n.Args, env)
+	result := callFunction(callee, args)
+	if _, ok := callee.(Builtin); ok {
+		env.state.alloc(result)
+	}
+	return result
+}
+
+func callFunction(callee any, args []any) any {
+	switch fn := callee.(type) {
+	case Function:
+		if fn.IsGenerator {
+			return newGenerator(fn, func() *Environment {
+				return argsToEnvironment(fn, args, true)
+			})
+		}
+		return applyFunction(fn, args, true)
+	case Builtin:
+		for _, arg := range args {
+			if _, ok := arg.(namedArgument); ok {
+				raiseError("%s does not accept named arguments", fn.Name)
+			}
+		}
+		return fn.Fn(args)
+	case *StructType:
+		return fn.construct(args)
+	case *Class:
+		return fn.instantiate(args)
+	case BoundMethod:
+		return fn.call(args)
+	case *EnumVariant:
+		return fn.construct(args)
+	default:
+		raiseError("%s is not callable", TypeName(callee))
+	}
+	return nil
+}
+
+func argsToEnvironment(fn Function, args []any, fresh bool) *Environment {
+	names := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		names[i] = param.Lexeme.Text
+	}
+	args, missing := bindArguments(fn.Name, "argument", names, args)
+	if len(missing) > 0 {
+		raiseError("%s expects %d arguments, missing %s", fn.Name, len(fn.Params), strings.Join(missing, ", "))
+	}
+	env := fn.Env
+	if fresh {
+		env = CreateEnvironment(fn.Env)
+	}
+	for i, param := range fn.Params {
+		if !matchesType(fn.ParamType(i), args[i], fn.Env) {
+			raiseError("%s: argument %s must be %s, got %s", fn.Name, param.Lexeme.Text, fn.ParamType(i), TypeName(args[i]))
+		}
+		env.SetVariable(*param, args[i])
+	}
+	return env
+}
+
+func bindArguments(callee, kind string, names []string, args []any) ([]any, []string) {
+	bound := make([]any, len(names))
+	set := make([]bool, len(names))
+	positional := 0
+	sawNamed := false
+	for _, arg := range args {
+		named, ok := arg.(namedArgument)
+		if !ok {
+			if sawNamed {
+				raiseError("%s: positional %s after named %s", callee, kind, kind)
+			}
+			if positional >= len(names) {
+				raiseError("%s expects %d %ss, got %d", callee, len(names), kind, len(args))
+			}
+			bound[positional] = arg
+			set[positional] = true
+			positional++
+			continue
+		}
+		sawNamed = true
+		i := indexOfString(names, named.Name)
+		if i < 0 {
+			raiseError("%s has no %s %s", callee, kind, named.Name)
+		}
+		if set[i] {
+			raiseError("%s: %s %s given more than once", callee, kind, named.Name)
+		}
+		bound[i] = named.Value
+		set[i] = true
+	}
+	missing := []string{}
+	for i, ok := range set {
+		if !ok {
+			missing = append(missing, names[i])
+		}
+	}
+	return bound, missing
+}
+
+func indexOfString(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func applyFunction(fn Function, args []any, fresh bool) any {
+	fn.Env.checkCancelled()
+	fn.Env.state.enter()
+	defer fn.Env.state.leave()
+	newEnv := argsToEnvironment(fn, args, fresh)
+	return checkReturn(fn, unwrapReturn(Eval(fn.Body, newEnv)))
+}
+
+type breakSignal struct{}
+
+func evalBreakStmt(n ast.BreakStmt, env *Environment) any {
+	return breakSignal{}
+}
+
+func evalForStmt(n ast.ForStmt, env *Environment) any {
+	var it Iterator
+	var subject any
+	if rng, ok := n.Target.(ast.RangeExpr); ok {
+		it = newRangeIterator(rng, env)
+	} else {
+		subject = Eval(n.Target, env)
+		it = iterate(subject)
+	}
+	defer it.Close()
+	_, keyed := subject.(*Map)
+	fn := Function{
+		FunctionLiteral: ast.FunctionLiteral{
+			Body:   n.Body,
+			Params: []*ast.Ident{n.Key},
+		},
+		Env: env,
+	}
+	if n.Value != nil {
+		fn.Params = append(fn.Params, n.Value)
+	}
+	for {
+		env.checkCancelled()
+		k, v, ok := it.Next()
+		if !ok {
+			break
+		}
+		args := []any{v}
+		if n.Value != nil {
+			args = []any{k, v}
+		} else if keyed {
+			args = []any{k}
+		}
+		switch result := Eval(fn.Body, argsToEnvironment(fn, args, false)).(type) {
+		case breakSignal:
+			return nil
+		case returnSignal:
+			return result
+		}
+	}
+	return nil
+}
+
+func newRangeIterator(n ast.RangeExpr, env *Environment) *rangeIterator {
+	from := rangeBound(Eval(n.From, env), "start")
+	to := rangeBound(Eval(n.To, env), "end")
+	step := 1
+	if n.Step != nil {
+		step = rangeBound(Eval(n.Step, env), "step")
+	}
+	ascending := from < to
+	if !ascending && step > 0 {
+		step *= -1
+	}
+	return &rangeIterator{next: from, to: to, step: step, ascending: ascending}
+}
+
+func rangeBound(v any, name string) int {
+	i, ok := v.(int)
+	if !ok {
+		raiseError("range %s must be an integer, got %s", name, TypeName(v))
+	}
+	return i
+}
+
+func evalRangeExpr(n ast.RangeExpr, env *Environment) any {
+	return NewArray(collect(env, newRangeIterator(n, env)))
+}
+
+func evalSwapStmt(n ast.SwapStmt, env *Environment) any {
+	t := Eval(n.A, env)
+	env.SetVariable(n.A, Eval(n.B, env))
+	env.SetVariable(n.B, t)
+	return nil
+}
+
+func evalImportStmt(n ast.ImportStmt, env *Environment) any {
+	t, ok := Eval(n.File, env).(string)
+	if !ok {
+		raiseError("import expects a string path")
+	}
+	env.state.allowPath("import", t, false)
+	input, err := os.ReadFile(t)
+	if err != nil {
+		raiseError("%s", err)
+	}
+	nodes, err := parser.Parse(string(input))
+	if err != nil {
+		raiseError("import %s: %v", t, err)
+	}
+	evaluateProgram(nodes, env)
+	return nil
+}
+
+func evalInputStmt(n ast.InputStmt, env *Environment) any {
+	env.state.allow("input", env.state.sandbox != nil && env.state.sandbox.Stdin)
+	prompt := Eval(n.Prompt, env)
+	fmt.Fprint(env.state.stdout, formatValue(prompt))
+	text, _ := env.state.stdin.ReadString('\n')
+	return text
+}
+
+func evalLengthExpr(n ast.LengthExpr, env *Environment) any {
+	v := Eval(n.Target, env)
+	switch t := v.(type) {
+	case string:
+		return utf8.RuneCountInString(t)
+	case *Map:
+		return t.Len()
+	case *Array:
+		return len(t.Elements)
+	case Tuple:
+		return len(t)
+	}
+	raiseError("len of %s", TypeName(v))
+	return nil
+}
+
+// RunNodes evaluates nodes in env until they run out, an error occurs or
+// ctx is done. Runtime errors and cancellation are returned as errors.
+func RunNodes(ctx context.Context, nodes chan ast.Node, env *Environment) (result any, err error) {
+	defer func() {
+		if err != nil {
+			for range nodes {
+			}
+		}
+	}()
+	env.state.reset(ctx)
+	defer env.state.closeOpen()
+	defer catchError(&err)
+	return EvaluateNodes(nodes, env), nil
+}
+
+// RunProgram is RunNodes for a program that has already been parsed. The
+// nodes are only read, so one program may run in many environments at once.
+func RunProgram(ctx context.Context, nodes []ast.Node, env *Environment) (result any, err error) {
+	env.state.reset(ctx)
+	defer env.state.closeOpen()
+	defer catchError(&err)
+	return evaluateProgram(nodes, env), nil
+}
+
+func evaluateProgram(nodes []ast.Node, env *Environment) any {
+	var result any
+	for _, node := range nodes {
+		env.checkCancelled()
+		result = Eval(node, env)
+		if r, ok := result.(returnSignal); ok {
+			return r.value
+		}
+	}
+	return result
+}
+
+func catchError(err *error) {
+	if r := recover(); r != nil {
+		switch r.(type) {
+		case *RuntimeError, *CancelledError, *LimitError, *PermissionError:
+			*err = r.(error)
+		default:
+			panic(r)
+		}
+	}
+}
+
+func EvaluateNodes(nodes chan ast.Node, env *Environment) any {
+	var result any
+	for node := range nodes {
+		env.checkCancelled()
+		result = Eval(node, env)
+		if r, ok := result.(returnSignal); ok {
+			for range nodes {
+			}
+			return r.value
+		}
+	}
+	return result
+}
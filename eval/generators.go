@@ -0,0 +1,208 @@
+package eval
+
+import "github.com/basemax/goscript/ast"
+
+type Generator struct {
+	Name string
+	fn   Function
+	// bind builds the environment the body runs in, with the arguments and,
+	// for a method, self and super bound.
+	bind   func() *Environment
+	out    chan generatorMsg
+	resume chan bool
+	// cancel is the run's context's Done channel. The body selects on it
+	// while suspended, and next and close stop resuming the body once it
+	// is closed, so a cancelled run ends even if the body is mid-yield.
+	cancel  <-chan struct{}
+	started bool
+	running bool
+	done    bool
+	index   int
+}
+
+type generatorMsg struct {
+	value    any
+	finished bool
+	failure  any
+}
+
+type generatorStop struct{}
+
+var generatorMethods map[string]methodFunc
+
+func init() {
+	generatorMethods = map[string]methodFunc{
+		"next": func(recv any, args []any) any {
+			checkArgCount("next", args, 0)
+			v, ok := recv.(*Generator).next()
+			if !ok {
+				return Stop
+			}
+			return v
+		},
+		"done": func(recv any, args []any) any {
+			checkArgCount("done", args, 0)
+			return recv.(*Generator).done
+		},
+		"close": func(recv any, args []any) any {
+			checkArgCount("close", args, 0)
+			recv.(*Generator).close()
+			return nil
+		},
+	}
+}
+
+func newGenerator(fn Function, bind func() *Environment) *Generator {
+	return &Generator{
+		Name:   fn.Name,
+		fn:     fn,
+		bind:   bind,
+		out:    make(chan generatorMsg),
+		resume: make(chan bool),
+	}
+}
+
+func (g *Generator) run() {
+	defer func() {
+		msg := generatorMsg{finished: true}
+		if r := recover(); r != nil {
+			if _, stopped := r.(generatorStop); !stopped {
+				msg.failure = r
+			}
+		}
+		g.out <- msg
+	}()
+	g.fn.Env.checkCancelled()
+	env := g.bind()
+	env.generator = g
+	Eval(g.fn.Body, env)
+}
+
+func (g *Generator) next() (any, bool) {
+	if g.running {
+		raiseError("generator %s is already running", g.Name)
+	}
+	if g.done {
+		return nil, false
+	}
+	g.fn.Env.checkCancelled()
+	// The body runs nested inside next until it yields, so it counts
+	// towards the call depth like any other call.
+	state := g.fn.Env.state
+	state.enter()
+	defer state.leave()
+	g.running = true
+	defer func() { g.running = false }()
+	if g.started {
+		g.send(true)
+	} else {
+		g.started = true
+		g.cancel = state.ctx.Done()
+		state.open = append(state.open, g)
+		go g.run()
+	}
+	msg := g.receive()
+	if msg.finished {
+		g.done = true
+		if msg.failure != nil {
+			panic(msg.failure)
+		}
+		return nil, false
+	}
+	return msg.value, true
+}
+
+func (g *Generator) Next() (any, any, bool) {
+	v, ok := g.next()
+	if !ok {
+		return nil, nil, false
+	}
+	g.index++
+	return g.index - 1, v, true
+}
+
+func (g *Generator) Close() {
+	g.close()
+}
+
+func (g *Generator) close() {
+	if g.running {
+		raiseError("generator %s cannot close itself", g.Name)
+	}
+	if g.done {
+		return
+	}
+	g.done = true
+	if g.started {
+		select {
+		case g.resume <- false:
+		case <-g.cancel:
+		}
+		g.wait()
+	}
+}
+
+// send resumes the suspended body. If the run is cancelled first, the body
+// stops on its own and the cancellation is raised here.
+func (g *Generator) send(resume bool) {
+	select {
+	case g.resume <- resume:
+	case <-g.cancel:
+		g.cancelled()
+	}
+}
+
+func (g *Generator) receive() generatorMsg {
+	select {
+	case msg := <-g.out:
+		return msg
+	case <-g.cancel:
+		g.cancelled()
+		return generatorMsg{}
+	}
+}
+
+func (g *Generator) cancelled() {
+	g.done = true
+	g.wait()
+	panic(&CancelledError{Cause: g.fn.Env.state.ctx.Err()})
+}
+
+// wait discards what the body sends until it finishes. The body shares the
+// run's state, so it must be done before the caller goes on.
+func (g *Generator) wait() {
+	for !(<-g.out).finished {
+	}
+}
+
+func (g *Generator) yield(v any) {
+	select {
+	case g.out <- generatorMsg{value: v}:
+	case <-g.cancel:
+		panic(generatorStop{})
+	}
+	select {
+	case resume := <-g.resume:
+		if !resume {
+			panic(generatorStop{})
+		}
+	case <-g.cancel:
+		panic(generatorStop{})
+	}
+}
+
+func (g *Generator) String() string {
+	return "<generator " + g.Name + ">"
+}
+
+func evalYieldStmt(n ast.YieldStmt, env *Environment) any {
+	v := Eval(n.Expr, env)
+	for e := env; e != nil; e = e.parent {
+		if e.generator != nil {
+			e.generator.yield(v)
+			return nil
+		}
+	}
+	raiseError("yield outside of a generator function")
+	return nil
+}
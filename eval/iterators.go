@@ -0,0 +1,247 @@
+package eval
+
+import (
+	"bufio"
+	"os"
+)
+
+// Iterator is implemented by every value a for loop can consume. Next
+// returns the key (an index for sequences) and the value of each element;
+// Close releases resources when the loop ends early.
+type Iterator interface {
+	Next() (key any, value any, ok bool)
+	Close()
+}
+
+// Iterable values produce a fresh Iterator for each loop.
+type Iterable interface {
+	Iter() Iterator
+}
+
+// Stop is what next() returns once an iterator or generator is exhausted,
+// and what a class's next method returns to end a loop. Scripts call it
+// stop. Unlike nil it cannot be mistaken for an element.
+var Stop = stopValue{}
+
+type stopValue struct{}
+
+func (stopValue) String() string {
+	return "stop"
+}
+
+type IteratorValue struct {
+	it   Iterator
+	done bool
+}
+
+var iteratorMethods = map[string]methodFunc{
+	"next": func(recv any, args []any) any {
+		checkArgCount("next", args, 0)
+		_, v, ok := recv.(*IteratorValue).Next()
+		if !ok {
+			return Stop
+		}
+		return v
+	},
+	"done": func(recv any, args []any) any {
+		checkArgCount("done", args, 0)
+		return recv.(*IteratorValue).done
+	},
+	"close": func(recv any, args []any) any {
+		checkArgCount("close", args, 0)
+		recv.(*IteratorValue).Close()
+		return nil
+	},
+}
+
+func (v *IteratorValue) Next() (any, any, bool) {
+	if v.done {
+		return nil, nil, false
+	}
+	k, val, ok := v.it.Next()
+	if !ok {
+		v.done = true
+	}
+	return k, val, ok
+}
+
+func (v *IteratorValue) Close() {
+	if !v.done {
+		v.done = true
+		v.it.Close()
+	}
+}
+
+func (v *IteratorValue) String() string {
+	return "<iterator>"
+}
+
+func iterate(v any) Iterator {
+	switch t := v.(type) {
+	case string:
+		return &sliceIterator{items: runesToStrings(t)}
+	case *Array:
+		return &sliceIterator{items: t.Elements}
+	case Tuple:
+		return &sliceIterator{items: t}
+	case *Map:
+		return &mapIterator{m: t}
+	case *Enum:
+		return &sliceIterator{items: t.members()}
+	case Iterator:
+		return t
+	case Iterable:
+		return t.Iter()
+	case chan any:
+		return &chanIterator{ch: t}
+	case <-chan any:
+		return &chanIterator{ch: t}
+	case *Object:
+		if m, ok := t.method("iter"); ok {
+			return iterate(m.call(nil))
+		}
+		if m, ok := t.method("next"); ok {
+			return &objectIterator{obj: t, next: m}
+		}
+	}
+	raiseError("cannot iterate over %s", TypeName(v))
+	return nil
+}
+
+func collect(env *Environment, it Iterator) []any {
+	defer it.Close()
+	ret := []any{}
+	for {
+		env.checkCancelled()
+		_, v, ok := it.Next()
+		if !ok {
+			return ret
+		}
+		ret = append(ret, v)
+		env.state.checkSize(ret)
+		env.state.charge(16)
+	}
+}
+
+func runesToStrings(s string) []any {
+	ret := []any{}
+	for _, r := range s {
+		ret = append(ret, string(r))
+	}
+	return ret
+}
+
+type sliceIterator struct {
+	items []any
+	index int
+}
+
+func (it *sliceIterator) Next() (any, any, bool) {
+	if it.index >= len(it.items) {
+		return nil, nil, false
+	}
+	it.index++
+	return it.index - 1, it.items[it.index-1], true
+}
+
+func (it *sliceIterator) Close() {}
+
+type rangeIterator struct {
+	next      int
+	to        int
+	step      int
+	ascending bool
+	index     int
+}
+
+func (it *rangeIterator) Next() (any, any, bool) {
+	if (it.ascending && it.next > it.to) || (!it.ascending && it.next < it.to) {
+		return nil, nil, false
+	}
+	v := it.next
+	it.next += it.step
+	it.index++
+	return it.index - 1, v, true
+}
+
+func (it *rangeIterator) Close() {}
+
+type chanIterator struct {
+	ch    <-chan any
+	index int
+}
+
+func (it *chanIterator) Next() (any, any, bool) {
+	v, ok := <-it.ch
+	if !ok {
+		return nil, nil, false
+	}
+	it.index++
+	return it.index - 1, v, true
+}
+
+func (it *chanIterator) Close() {}
+
+type objectIterator struct {
+	obj   *Object
+	next  BoundMethod
+	index int
+	done  bool
+}
+
+func (it *objectIterator) Next() (any, any, bool) {
+	if it.done {
+		return nil, nil, false
+	}
+	v := it.next.call(nil)
+	if v == Stop {
+		it.done = true
+		return nil, nil, false
+	}
+	it.index++
+	return it.index - 1, v, true
+}
+
+func (it *objectIterator) Close() {
+	if it.done {
+		return
+	}
+	it.done = true
+	if m, ok := it.obj.method("close"); ok {
+		m.call(nil)
+	}
+}
+
+type lineIterator struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	index   int
+}
+
+func (it *lineIterator) Next() (any, any, bool) {
+	if it.file == nil || !it.scanner.Scan() {
+		it.Close()
+		return nil, nil, false
+	}
+	it.index++
+	return it.index - 1, it.scanner.Text(), true
+}
+
+func (it *lineIterator) Close() {
+	if it.file != nil {
+		it.file.Close()
+		it.file = nil
+	}
+}
+
+func (it *lineIterator) String() string {
+	return "<lines>"
+}
+
+func builtinIter(args []any) any {
+	checkArgCount("iter", args, 1)
+	if v, ok := args[0].(*IteratorValue); ok {
+		return v
+	}
+	return &IteratorValue{it: iterate(args[0])}
+}
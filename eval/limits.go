@@ -0,0 +1,167 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DefaultMaxCallDepth bounds recursion when Limits.MaxCallDepth is zero, so
+// runaway recursion fails with an error rather than exhausting the Go stack.
+const DefaultMaxCallDepth = 10000
+
+// Limits bounds the resources one run may use. A zero field means no limit,
+// except MaxCallDepth which falls back to DefaultMaxCallDepth. MaxMemory is
+// approximate: it counts bytes allocated for strings, arrays and maps over
+// the whole run, not bytes currently live.
+type Limits struct {
+	MaxSteps     int
+	MaxCallDepth int
+	MaxStringLen int
+	MaxArrayLen  int
+	MaxMapLen    int
+	MaxMemory    int
+}
+
+var (
+	ErrStepLimit   = errors.New("step limit exceeded")
+	ErrCallDepth   = errors.New("call depth limit exceeded")
+	ErrStringLimit = errors.New("string size limit exceeded")
+	ErrArrayLimit  = errors.New("array size limit exceeded")
+	ErrMapLimit    = errors.New("map size limit exceeded")
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+)
+
+// LimitError reports which limit a run exceeded. It unwraps to one of the
+// Err*Limit values above.
+type LimitError struct {
+	Err error
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %d)", e.Err, e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+func (env *Environment) SetLimits(l Limits) {
+	env.state.limits = l
+}
+
+func (s *runState) exceeded(err error, max int) {
+	panic(&LimitError{Err: err, Max: max})
+}
+
+func (s *runState) step() {
+	s.steps++
+	if s.limits.MaxSteps > 0 && s.steps > s.limits.MaxSteps {
+		s.exceeded(ErrStepLimit, s.limits.MaxSteps)
+	}
+}
+
+func (s *runState) enter() {
+	max := s.limits.MaxCallDepth
+	if max == 0 {
+		max = DefaultMaxCallDepth
+	}
+	s.depth++
+	if s.depth > max {
+		s.depth = 0
+		s.exceeded(ErrCallDepth, max)
+	}
+}
+
+func (s *runState) leave() {
+	if s.depth > 0 {
+		s.depth--
+	}
+}
+
+func (s *runState) checkSize(v any) {
+	l := s.limits
+	switch t := v.(type) {
+	case string:
+		if l.MaxStringLen > 0 && len(t) > l.MaxStringLen {
+			s.exceeded(ErrStringLimit, l.MaxStringLen)
+		}
+	case *Array:
+		s.checkSize(t.Elements)
+	case []any:
+		if l.MaxArrayLen > 0 && len(t) > l.MaxArrayLen {
+			s.exceeded(ErrArrayLimit, l.MaxArrayLen)
+		}
+	case Tuple:
+		if l.MaxArrayLen > 0 && len(t) > l.MaxArrayLen {
+			s.exceeded(ErrArrayLimit, l.MaxArrayLen)
+		}
+	case *Map:
+		if l.MaxMapLen > 0 && t.Len() > l.MaxMapLen {
+			s.exceeded(ErrMapLimit, l.MaxMapLen)
+		}
+	}
+}
+
+// alloc charges the approximate size of a newly created value against the
+// memory limit and checks it against the size limits.
+func (s *runState) alloc(v any) {
+	s.checkSize(v)
+	s.charge(sizeOf(v))
+}
+
+func (s *runState) charge(bytes int) {
+	if s.limits.MaxMemory == 0 {
+		return
+	}
+	s.allocated += bytes
+	if s.allocated > s.limits.MaxMemory {
+		s.exceeded(ErrMemoryLimit, s.limits.MaxMemory)
+	}
+}
+
+// checkString rejects a string of size bytes before it is built, for
+// operations whose result may be far larger than anything the limits allow.
+func (s *runState) checkString(size int) {
+	if l := s.limits.MaxStringLen; l > 0 && size > l {
+		s.exceeded(ErrStringLimit, l)
+	}
+	if l := s.limits.MaxMemory; l > 0 && size > l-s.allocated {
+		s.exceeded(ErrMemoryLimit, l)
+	}
+}
+
+// checkArray is checkString for an array of n elements.
+func (s *runState) checkArray(n int) {
+	if l := s.limits.MaxArrayLen; l > 0 && n > l {
+		s.exceeded(ErrArrayLimit, l)
+	}
+	if l := s.limits.MaxMemory; l > 0 && n > (l-s.allocated)/16 {
+		s.exceeded(ErrMemoryLimit, l)
+	}
+}
+
+// grownSize returns size + count*each, saturating at math.MaxInt.
+func grownSize(size, count, each int) int {
+	if each > 0 && count > (math.MaxInt-size)/each {
+		return math.MaxInt
+	}
+	return size + count*each
+}
+
+func sizeOf(v any) int {
+	switch t := v.(type) {
+	case string:
+		return len(t)
+	case *Array:
+		return sizeOf(t.Elements)
+	case []any:
+		return 24 + 16*len(t)
+	case Tuple:
+		return 24 + 16*len(t)
+	case *Map:
+		return 48 + 64*t.Len()
+	}
+	return 0
+}
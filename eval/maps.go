@@ -0,0 +1,237 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Map is the runtime representation of script maps. It remembers the order
+// in which keys were first inserted so iteration and printing are stable.
+type Map struct {
+	keys   []any
+	values []any
+	index  map[any]int
+}
+
+func NewMap() *Map {
+	return &Map{index: map[any]int{}}
+}
+
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+func (m *Map) Get(k any) (any, bool) {
+	i, ok := m.index[hashKey(k)]
+	if !ok {
+		return nil, false
+	}
+	return m.values[i], true
+}
+
+func (m *Map) Set(k, v any) {
+	h := hashKey(k)
+	if i, ok := m.index[h]; ok {
+		m.values[i] = v
+		return
+	}
+	m.index[h] = len(m.keys)
+	m.keys = append(m.keys, k)
+	m.values = append(m.values, v)
+}
+
+func (m *Map) Delete(k any) (any, bool) {
+	h := hashKey(k)
+	i, ok := m.index[h]
+	if !ok {
+		return nil, false
+	}
+	v := m.values[i]
+	delete(m.index, h)
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+	m.values = append(m.values[:i], m.values[i+1:]...)
+	for j := i; j < len(m.keys); j++ {
+		m.index[hashKey(m.keys[j])] = j
+	}
+	return v, true
+}
+
+func (m *Map) equal(other *Map, seen visits) bool {
+	if m.Len() != other.Len() {
+		return false
+	}
+	if m == other {
+		return true
+	}
+	key := [2]any{m, other}
+	seen.enter(key, m, "compare")
+	defer seen.leave(key)
+	for i, k := range m.keys {
+		v, ok := other.Get(k)
+		if !ok || !equal(m.values[i], v, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Map) Keys() []any {
+	return append([]any{}, m.keys...)
+}
+
+func (m *Map) Values() []any {
+	return append([]any{}, m.values...)
+}
+
+func (m *Map) Sorted() *Map {
+	order := make([]int, len(m.keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return compareValues(m.keys[order[a]], m.keys[order[b]]) < 0
+	})
+	sorted := NewMap()
+	for _, i := range order {
+		sorted.Set(m.keys[i], m.values[i])
+	}
+	return sorted
+}
+
+func (m *Map) String() string {
+	return m.format(visits{})
+}
+
+func (m *Map) format(seen visits) string {
+	seen.enter(m, m, "format")
+	defer seen.leave(m)
+	parts := make([]string, len(m.keys))
+	for i, k := range m.keys {
+		parts[i] = format(k, seen) + ":" + format(m.values[i], seen)
+	}
+	return "map[" + strings.Join(parts, " ") + "]"
+}
+
+type compositeKey struct {
+	encoded string
+}
+
+// hashKey maps a script value to a comparable Go value so that values which
+// are == in the script land on the same map entry. Mutable containers are
+// rejected; tuples and enum values are encoded element by element.
+func hashKey(k any) any {
+	switch t := k.(type) {
+	case nil, bool, string, int:
+		return k
+	case *big.Int:
+		return compositeKey{encoded: "int:" + t.String()}
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return k
+		}
+		return hashKey(toDecimal(t))
+	case Decimal:
+		n := t.normalized()
+		if n.Scale <= 0 {
+			return hashKey(normalizeBigInt(n.Round(0, RoundDown).Unscaled))
+		}
+		return compositeKey{encoded: "decimal:" + n.String()}
+	case *Object:
+		if _, ok := t.method("__eq__"); ok {
+			raiseError("unhashable type %s", TypeName(k))
+		}
+		return k
+	case *Class, *StructType, *Enum, *EnumVariant:
+		return k
+	case Tuple, *EnumValue:
+		var b strings.Builder
+		writeKey(&b, k)
+		return compositeKey{encoded: b.String()}
+	}
+	raiseError("unhashable type %s, use a tuple as a composite key", TypeName(k))
+	return nil
+}
+
+func writeKey(b *strings.Builder, k any) {
+	switch t := k.(type) {
+	case Tuple:
+		b.WriteString("(" + strconv.Itoa(len(t)))
+		for _, v := range t {
+			b.WriteByte(',')
+			writeKey(b, v)
+		}
+		b.WriteByte(')')
+	case *EnumValue:
+		fmt.Fprintf(b, "%p(", t.Variant)
+		writeKey(b, Tuple(t.Values))
+		b.WriteByte(')')
+	default:
+		h := hashKey(k)
+		if c, ok := h.(compositeKey); ok {
+			b.WriteString(c.encoded)
+			return
+		}
+		var s string
+		switch h.(type) {
+		case *Object, *Class, *StructType, *Enum, *EnumVariant:
+			s = fmt.Sprintf("%T:%p", h, h)
+		default:
+			s = fmt.Sprintf("%T:%v", h, h)
+		}
+		b.WriteString(strconv.Itoa(len(s)) + ":" + s)
+	}
+}
+
+// compareValues orders values for sorting: numbers numerically, strings and
+// booleans naturally, and values of different types by their type name.
+func compareValues(a, b any) int {
+	if l, r, ok := coerceNumbers(a, b); ok {
+		return compareNumbers(l, r)
+	}
+	switch l := a.(type) {
+	case string:
+		if r, ok := b.(string); ok {
+			return strings.Compare(l, r)
+		}
+	case bool:
+		if r, ok := b.(bool); ok {
+			switch {
+			case l == r:
+				return 0
+			case r:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(TypeName(a), TypeName(b))
+}
+
+func compareOrdered[T int | float64 | string](l, r T) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+type mapIterator struct {
+	m     *Map
+	index int
+}
+
+func (it *mapIterator) Next() (any, any, bool) {
+	if it.index >= len(it.m.keys) {
+		return nil, nil, false
+	}
+	it.index++
+	return it.m.keys[it.index-1], it.m.values[it.index-1], true
+}
+
+func (it *mapIterator) Close() {}
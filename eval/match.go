@@ -0,0 +1,177 @@
+package eval
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/basemax/goscript/ast"
+)
+
+func evalMatchExpr(n ast.MatchExpr, env *Environment) any {
+	subject := Eval(n.Subject, env)
+	checkExhaustive(n, env)
+	for _, arm := range n.Arms {
+		bindings := map[string]any{}
+		if matchPattern(arm.Pattern, subject, env, bindings) {
+			return evalArm(arm.Body, bindings, env)
+		}
+	}
+	raiseError("no match arm for %s", formatValue(subject))
+	return nil
+}
+
+// evalArm evaluates an arm body in a scope holding the pattern's bindings,
+// so they shadow outer variables only inside the arm. Other variables the
+// body assigns belong to the enclosing scope, as they would without match.
+func evalArm(body ast.Node, bindings map[string]any, env *Environment) any {
+	scope := CreateEnvironment(env)
+	maps.Copy(scope.variables, bindings)
+	ret := Eval(body, scope)
+	for name, v := range scope.variables {
+		if _, bound := bindings[name]; !bound {
+			env.variables[name] = v
+		}
+	}
+	maps.Copy(env.functions, scope.functions)
+	return ret
+}
+
+func matchPattern(pattern ast.Node, v any, env *Environment, bindings map[string]any) bool {
+	switch p := pattern.(type) {
+	case ast.Ident:
+		if p.Lexeme.Text != "_" {
+			bindings[p.Lexeme.Text] = v
+		}
+		return true
+	case ast.MemberExpr:
+		if variant, ok := patternVariant(p.Object, p.Name, env); ok {
+			value, isEnum := v.(*EnumValue)
+			return isEnum && value.Variant == variant
+		}
+	case ast.MethodCall:
+		if variant, ok := patternVariant(p.Object, p.Name, env); ok {
+			value, isEnum := v.(*EnumValue)
+			if !isEnum || value.Variant != variant {
+				return false
+			}
+			return matchFields(p.Args, variant.Fields, value.Values, variant.String(), env, bindings)
+		}
+	case ast.CallExpr:
+		callee := Eval(p.Function, env)
+		if t, ok := callee.(*StructType); ok {
+			inst, isStruct := v.(*StructInstance)
+			if !isStruct || inst.Type != t {
+				return false
+			}
+			return matchFields(p.Args, t.Fields, inst.Values, t.Name, env, bindings)
+		}
+	case ast.ArrayLiteral:
+		arr, ok := v.(*Array)
+		return ok && matchElements(p.Elements, arr.Elements, env, bindings)
+	case ast.TupleLiteral:
+		tuple, ok := v.(Tuple)
+		return ok && matchElements(p.Elements, tuple, env, bindings)
+	}
+	return valuesEqual(Eval(pattern, env), v)
+}
+
+func matchElements(patterns []ast.Node, values []any, env *Environment, bindings map[string]any) bool {
+	if len(patterns) != len(values) {
+		return false
+	}
+	for i, pattern := range patterns {
+		if !matchPattern(pattern, values[i], env, bindings) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchFields(patterns []ast.Node, fields []string, values []any, name string, env *Environment, bindings map[string]any) bool {
+	positional := 0
+	for _, pattern := range patterns {
+		i := positional
+		if named, ok := pattern.(ast.NamedArg); ok {
+			i = indexOfString(fields, named.Name)
+			if i < 0 {
+				raiseError("%s has no field %s", name, named.Name)
+			}
+			pattern = named.Value
+		} else {
+			positional++
+		}
+		if i >= len(values) {
+			raiseError("pattern for %s has too many fields", name)
+		}
+		if !matchPattern(pattern, values[i], env, bindings) {
+			return false
+		}
+	}
+	return true
+}
+
+func patternVariant(object ast.Node, name string, env *Environment) (*EnumVariant, bool) {
+	enum, ok := Eval(object, env).(*Enum)
+	if !ok {
+		return nil, false
+	}
+	variant, ok := enum.variant(name)
+	if !ok {
+		raiseError("enum %s has no variant %s", enum.Name, name)
+	}
+	return variant, true
+}
+
+// checkExhaustive rejects a match over enum variants without a catch-all arm
+// unless every variant of the enum is covered by an irrefutable arm.
+func checkExhaustive(n ast.MatchExpr, env *Environment) {
+	var enum *Enum
+	covered := map[*EnumVariant]bool{}
+	for _, arm := range n.Arms {
+		var variant *EnumVariant
+		complete := true
+		switch p := arm.Pattern.(type) {
+		case ast.Ident:
+			return
+		case ast.MemberExpr:
+			variant, _ = patternVariant(p.Object, p.Name, env)
+		case ast.MethodCall:
+			variant, _ = patternVariant(p.Object, p.Name, env)
+			complete = irrefutable(p.Args)
+		}
+		if variant == nil {
+			continue
+		}
+		if enum != nil && variant.Enum != enum {
+			return
+		}
+		enum = variant.Enum
+		if complete {
+			covered[variant] = true
+		}
+	}
+	if enum == nil {
+		return
+	}
+	missing := []string{}
+	for _, variant := range enum.Variants {
+		if !covered[variant] {
+			missing = append(missing, variant.String())
+		}
+	}
+	if len(missing) > 0 {
+		raiseError("match on %s is not exhaustive, missing %s", enum.Name, strings.Join(missing, ", "))
+	}
+}
+
+func irrefutable(patterns []ast.Node) bool {
+	for _, pattern := range patterns {
+		if named, ok := pattern.(ast.NamedArg); ok {
+			pattern = named.Value
+		}
+		if _, ok := pattern.(ast.Ident); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,355 @@
+package eval
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/basemax/goscript/ast"
+)
+
+type methodFunc func(recv any, args []any) any
+
+// arrayMutator changes an array in place and returns the method's result.
+type arrayMutator func(arr *Array, args []any) any
+
+var stringMethods = map[string]methodFunc{
+	"len": func(recv any, args []any) any {
+		checkArgCount("len", args, 0)
+		return utf8.RuneCountInString(recv.(string))
+	},
+	"upper": func(recv any, args []any) any {
+		checkArgCount("upper", args, 0)
+		return strings.ToUpper(recv.(string))
+	},
+	"lower": func(recv any, args []any) any {
+		checkArgCount("lower", args, 0)
+		return strings.ToLower(recv.(string))
+	},
+	"trim": func(recv any, args []any) any {
+		checkArgCount("trim", args, 0)
+		return strings.TrimSpace(recv.(string))
+	},
+	"split": func(recv any, args []any) any {
+		checkArgCount("split", args, 1)
+		ret := []any{}
+		for _, part := range strings.Split(recv.(string), stringArg("split", args, 0)) {
+			ret = append(ret, part)
+		}
+		return NewArray(ret)
+	},
+	"contains": func(recv any, args []any) any {
+		checkArgCount("contains", args, 1)
+		return strings.Contains(recv.(string), stringArg("contains", args, 0))
+	},
+	"startsWith": func(recv any, args []any) any {
+		checkArgCount("startsWith", args, 1)
+		return strings.HasPrefix(recv.(string), stringArg("startsWith", args, 0))
+	},
+	"endsWith": func(recv any, args []any) any {
+		checkArgCount("endsWith", args, 1)
+		return strings.HasSuffix(recv.(string), stringArg("endsWith", args, 0))
+	},
+	"indexOf": func(recv any, args []any) any {
+		checkArgCount("indexOf", args, 1)
+		s := recv.(string)
+		i := strings.Index(s, stringArg("indexOf", args, 0))
+		if i < 0 {
+			return -1
+		}
+		return utf8.RuneCountInString(s[:i])
+	},
+	"replace": func(recv any, args []any) any {
+		checkArgCount("replace", args, 2)
+		return strings.ReplaceAll(recv.(string), stringArg("replace", args, 0), stringArg("replace", args, 1))
+	},
+	"repeat": func(recv any, args []any) any {
+		checkArgCount("repeat", args, 1)
+		n := intArg("repeat", args, 0)
+		if n < 0 {
+			raiseError("repeat count cannot be negative")
+		}
+		if s := recv.(string); len(s) > 0 && n > math.MaxInt/len(s) {
+			raiseError("repeat count %d is too large", n)
+		}
+		return strings.Repeat(recv.(string), n)
+	},
+}
+
+var arrayMethods map[string]methodFunc
+
+func init() {
+	arrayMethods = map[string]methodFunc{
+		"len": func(recv any, args []any) any {
+			checkArgCount("len", args, 0)
+			return len(recv.([]any))
+		},
+		"contains": func(recv any, args []any) any {
+			checkArgCount("contains", args, 1)
+			return indexOfValue(recv.([]any), args[0]) >= 0
+		},
+		"indexOf": func(recv any, args []any) any {
+			checkArgCount("indexOf", args, 1)
+			return indexOfValue(recv.([]any), args[0])
+		},
+		"join": func(recv any, args []any) any {
+			checkArgCount("join", args, 1)
+			parts := []string{}
+			for _, v := range recv.([]any) {
+				parts = append(parts, formatValue(v))
+			}
+			return strings.Join(parts, stringArg("join", args, 0))
+		},
+		"reversed": func(recv any, args []any) any {
+			checkArgCount("reversed", args, 0)
+			arr := recv.([]any)
+			ret := make([]any, len(arr))
+			for i, v := range arr {
+				ret[len(arr)-1-i] = v
+			}
+			return NewArray(ret)
+		},
+	}
+}
+
+var arrayMutators = map[string]arrayMutator{
+	"push": func(arr *Array, args []any) any {
+		arr.Elements = append(arr.Elements, args...)
+		return len(arr.Elements)
+	},
+	"pop": func(arr *Array, args []any) any {
+		checkArgCount("pop", args, 0)
+		n := len(arr.Elements)
+		if n == 0 {
+			raiseError("pop from empty array")
+		}
+		last := arr.Elements[n-1]
+		arr.Elements = slices.Delete(arr.Elements, n-1, n)
+		return last
+	},
+	"insert": func(arr *Array, args []any) any {
+		checkArgCount("insert", args, 2)
+		n := len(arr.Elements)
+		i := intArg("insert", args, 0)
+		if i < 0 {
+			i += n
+		}
+		if i < 0 || i > n {
+			raiseError("insert index %d out of range [0:%d]", args[0], n)
+		}
+		arr.Elements = slices.Insert(arr.Elements, i, args[1])
+		return nil
+	},
+	"remove": func(arr *Array, args []any) any {
+		checkArgCount("remove", args, 1)
+		i := normalizeIndex(args[0], len(arr.Elements))
+		removed := arr.Elements[i]
+		arr.Elements = slices.Delete(arr.Elements, i, i+1)
+		return removed
+	},
+}
+
+var mapMethods = map[string]methodFunc{
+	"len": func(recv any, args []any) any {
+		checkArgCount("len", args, 0)
+		return recv.(*Map).Len()
+	},
+	"keys": func(recv any, args []any) any {
+		checkArgCount("keys", args, 0)
+		return NewArray(recv.(*Map).Keys())
+	},
+	"values": func(recv any, args []any) any {
+		checkArgCount("values", args, 0)
+		return NewArray(recv.(*Map).Values())
+	},
+	"has": func(recv any, args []any) any {
+		checkArgCount("has", args, 1)
+		_, ok := recv.(*Map).Get(args[0])
+		return ok
+	},
+	"get": func(recv any, args []any) any {
+		if len(args) != 1 && len(args) != 2 {
+			raiseError("get expects 1 or 2 arguments, got %d", len(args))
+		}
+		if v, ok := recv.(*Map).Get(args[0]); ok {
+			return v
+		}
+		if len(args) == 2 {
+			return args[1]
+		}
+		return nil
+	},
+	"remove": func(recv any, args []any) any {
+		checkArgCount("remove", args, 1)
+		v, _ := recv.(*Map).Delete(args[0])
+		return v
+	},
+	"sorted": func(recv any, args []any) any {
+		checkArgCount("sorted", args, 0)
+		return recv.(*Map).Sorted()
+	},
+}
+
+func evalMemberExpr(n ast.MemberExpr, env *Environment) any {
+	switch t := Eval(n.Object, env).(type) {
+	case Function, BoundMethod, Builtin:
+		if v, ok := functionInfo(t, n.Name); ok {
+			return v
+		}
+		raiseError("function has no attribute %s", n.Name)
+	case *Map:
+		v, _ := t.Get(n.Name)
+		return v
+	case *StructInstance:
+		return t.get(n.Name)
+	case *Object:
+		return t.get(n.Name)
+	case superRef:
+		return t.bind(n.Name)
+	case *Enum:
+		return t.member(n.Name)
+	case *EnumValue:
+		return t.get(n.Name)
+	default:
+		raiseError("cannot access field %s on %s", n.Name, TypeName(t))
+	}
+	return nil
+}
+
+// checkResultSize enforces the size limits on the methods whose result can
+// be much larger than their receiver and arguments, before the result is
+// built. Other results are checked by alloc once the method returns.
+func checkResultSize(s *runState, recv any, name string, args []any) {
+	switch r := recv.(type) {
+	case string:
+		switch name {
+		case "repeat":
+			if len(args) == 1 {
+				if count, ok := args[0].(int); ok && count > 0 {
+					s.checkString(grownSize(0, count, len(r)))
+				}
+			}
+		case "replace":
+			if strs, ok := stringArgs(args, 2); ok {
+				old, repl := strs[0], strs[1]
+				count := strings.Count(r, old)
+				s.checkString(grownSize(len(r)-count*len(old), count, len(repl)))
+			}
+		case "split":
+			if strs, ok := stringArgs(args, 1); ok {
+				if strs[0] == "" {
+					s.checkArray(utf8.RuneCountInString(r))
+				} else {
+					s.checkArray(strings.Count(r, strs[0]) + 1)
+				}
+			}
+		}
+	case *Array:
+		// Only the strings are counted: formatting the other elements here
+		// would run their __str__ methods twice.
+		if sep, ok := stringArgs(args, 1); ok && name == "join" && len(r.Elements) > 0 {
+			size := grownSize(0, len(r.Elements)-1, len(sep[0]))
+			for _, v := range r.Elements {
+				if str, ok := v.(string); ok {
+					size = grownSize(size, 1, len(str))
+				}
+			}
+			s.checkString(size)
+		}
+	}
+}
+
+// stringArgs returns args as strings when there are exactly n of them and
+// all are strings; otherwise the method itself reports the mistake.
+func stringArgs(args []any, n int) ([]string, bool) {
+	if len(args) != n {
+		return nil, false
+	}
+	ret := make([]string, n)
+	for i, v := range args {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		ret[i] = s
+	}
+	return ret, true
+}
+
+func evalMethodCall(n ast.MethodCall, env *Environment) any {
+	recv := Eval(n.Object, env)
+	args := evalExpressions(n.Args, env)
+	checkResultSize(env.state, recv, n.Name, args)
+	switch t := recv.(type) {
+	case *Map:
+		if fn, ok := t.Get(n.Name); ok && isCallable(fn) {
+			return callFunction(fn, args)
+		}
+	case *Object:
+		return callFunction(t.get(n.Name), args)
+	case superRef:
+		return t.bind(n.Name).call(args)
+	case *Enum:
+		return callFunction(t.member(n.Name), args)
+	}
+	if arr, ok := recv.(*Array); ok {
+		if mutate, ok := arrayMutators[n.Name]; ok {
+			before := len(arr.Elements)
+			result := mutate(arr, args)
+			env.state.checkSize(arr)
+			env.state.charge(16 * max(len(arr.Elements)-before, 0))
+			return result
+		}
+	}
+	if method, ok := methodsFor(recv)[n.Name]; ok {
+		var result any
+		switch t := recv.(type) {
+		case *Array:
+			result = method(t.Elements, args)
+		case Tuple:
+			result = method([]any(t), args)
+		default:
+			result = method(recv, args)
+		}
+		env.state.alloc(result)
+		return result
+	}
+	raiseError("%s has no method %s", TypeName(recv), n.Name)
+	return nil
+}
+
+func methodsFor(recv any) map[string]methodFunc {
+	switch recv.(type) {
+	case string:
+		return stringMethods
+	case *Array, Tuple:
+		return arrayMethods
+	case *Map:
+		return mapMethods
+	case Decimal:
+		return decimalMethods
+	case *Generator:
+		return generatorMethods
+	case *IteratorValue:
+		return iteratorMethods
+	}
+	return nil
+}
+
+func isCallable(v any) bool {
+	switch v.(type) {
+	case Function, Builtin, BoundMethod, *StructType, *Class, *EnumVariant:
+		return true
+	}
+	return false
+}
+
+func indexOfValue(arr []any, v any) int {
+	for i, el := range arr {
+		if valuesEqual(el, v) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,434 @@
+package eval
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact base-10 number: Unscaled × 10^-Scale.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+type RoundingMode string
+
+const (
+	RoundHalfEven RoundingMode = "half_even"
+	RoundHalfUp   RoundingMode = "half_up"
+	RoundHalfDown RoundingMode = "half_down"
+	RoundDown     RoundingMode = "down"
+	RoundUp       RoundingMode = "up"
+	RoundFloor    RoundingMode = "floor"
+	RoundCeiling  RoundingMode = "ceiling"
+)
+
+// DecimalDivisionDigits is the number of fractional digits kept, beyond the
+// operands' own scale, when dividing decimals with the / operator.
+const DecimalDivisionDigits = 16
+
+var bigTen = big.NewInt(10)
+
+const (
+	rankInt = iota
+	rankBigInt
+	rankFloat
+	rankDecimal
+)
+
+func numericRank(v any) (int, bool) {
+	switch v.(type) {
+	case int:
+		return rankInt, true
+	case *big.Int:
+		return rankBigInt, true
+	case float64:
+		return rankFloat, true
+	case Decimal:
+		return rankDecimal, true
+	}
+	return 0, false
+}
+
+// coerceNumbers promotes two numeric operands to the wider of their types:
+// int → big int → float → decimal.
+func coerceNumbers(l, r any) (any, any, bool) {
+	lr, lok := numericRank(l)
+	rr, rok := numericRank(r)
+	if !lok || !rok {
+		return nil, nil, false
+	}
+	rank := max(lr, rr)
+	return promoteNumber(l, rank), promoteNumber(r, rank), true
+}
+
+func promoteNumber(v any, rank int) any {
+	switch rank {
+	case rankBigInt:
+		if n, ok := v.(int); ok {
+			return big.NewInt(int64(n))
+		}
+	case rankFloat:
+		switch n := v.(type) {
+		case int:
+			return float64(n)
+		case *big.Int:
+			f, _ := new(big.Float).SetInt(n).Float64()
+			return f
+		}
+	case rankDecimal:
+		return toDecimal(v)
+	}
+	return v
+}
+
+func normalizeBigInt(n *big.Int) any {
+	if n.IsInt64() {
+		if v := n.Int64(); int64(int(v)) == v {
+			return int(v)
+		}
+	}
+	return n
+}
+
+func addInts(l, r int) any {
+	s := l + r
+	if (s > l) != (r > 0) {
+		return normalizeBigInt(new(big.Int).Add(big.NewInt(int64(l)), big.NewInt(int64(r))))
+	}
+	return s
+}
+
+func subInts(l, r int) any {
+	s := l - r
+	if (s < l) != (r > 0) {
+		return normalizeBigInt(new(big.Int).Sub(big.NewInt(int64(l)), big.NewInt(int64(r))))
+	}
+	return s
+}
+
+func mulInts(l, r int) any {
+	if l == 0 || r == 0 {
+		return 0
+	}
+	p := l * r
+	if p/r != l || (l == -1 && r == math.MinInt) || (r == -1 && l == math.MinInt) {
+		return normalizeBigInt(new(big.Int).Mul(big.NewInt(int64(l)), big.NewInt(int64(r))))
+	}
+	return p
+}
+
+func negateInt(n int) any {
+	if n == math.MinInt {
+		return new(big.Int).Neg(big.NewInt(int64(n)))
+	}
+	return -n
+}
+
+func evalBigBig(ll, rr any, operator string) any {
+	l := ll.(*big.Int)
+	r := rr.(*big.Int)
+	switch operator {
+	case ">":
+		return l.Cmp(r) > 0
+	case ">=":
+		return l.Cmp(r) >= 0
+	case "<":
+		return l.Cmp(r) < 0
+	case "<=":
+		return l.Cmp(r) <= 0
+	case "+":
+		return normalizeBigInt(new(big.Int).Add(l, r))
+	case "-":
+		return normalizeBigInt(new(big.Int).Sub(l, r))
+	case "*":
+		return normalizeBigInt(new(big.Int).Mul(l, r))
+	case "/":
+		if r.Sign() == 0 {
+			raiseError("integer division by zero")
+		}
+		return normalizeBigInt(new(big.Int).Quo(l, r))
+	}
+	raiseError("unsupported operator %s for int and int", operator)
+	return nil
+}
+
+func evalDecimalDecimal(ll, rr any, operator string) any {
+	l := ll.(Decimal)
+	r := rr.(Decimal)
+	switch operator {
+	case ">":
+		return l.Cmp(r) > 0
+	case ">=":
+		return l.Cmp(r) >= 0
+	case "<":
+		return l.Cmp(r) < 0
+	case "<=":
+		return l.Cmp(r) <= 0
+	case "+":
+		a, b, scale := alignDecimals(l, r)
+		return Decimal{Unscaled: new(big.Int).Add(a, b), Scale: scale}
+	case "-":
+		a, b, scale := alignDecimals(l, r)
+		return Decimal{Unscaled: new(big.Int).Sub(a, b), Scale: scale}
+	case "*":
+		return Decimal{Unscaled: new(big.Int).Mul(l.Unscaled, r.Unscaled), Scale: l.Scale + r.Scale}
+	case "/":
+		scale := max(l.Scale, r.Scale)
+		return l.Div(r, scale+DecimalDivisionDigits, RoundHalfEven).trimZeros(scale)
+	}
+	raiseError("unsupported operator %s for decimal and decimal", operator)
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func alignDecimals(l, r Decimal) (*big.Int, *big.Int, int) {
+	switch {
+	case l.Scale < r.Scale:
+		return new(big.Int).Mul(l.Unscaled, pow10(r.Scale-l.Scale)), r.Unscaled, r.Scale
+	case l.Scale > r.Scale:
+		return l.Unscaled, new(big.Int).Mul(r.Unscaled, pow10(l.Scale-r.Scale)), l.Scale
+	}
+	return l.Unscaled, r.Unscaled, l.Scale
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := alignDecimals(d, other)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{Unscaled: new(big.Int).Neg(d.Unscaled), Scale: d.Scale}
+}
+
+// Div divides d by other, rounding the quotient to scale fractional digits.
+func (d Decimal) Div(other Decimal, scale int, mode RoundingMode) Decimal {
+	if other.Unscaled.Sign() == 0 {
+		raiseError("decimal division by zero")
+	}
+	num := new(big.Int).Set(d.Unscaled)
+	den := new(big.Int).Set(other.Unscaled)
+	if shift := scale + other.Scale - d.Scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return Decimal{Unscaled: roundQuotient(num, den, mode), Scale: scale}
+}
+
+// Round rescales d to scale fractional digits using the given rounding mode.
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	if scale >= d.Scale {
+		return Decimal{Unscaled: new(big.Int).Mul(d.Unscaled, pow10(scale-d.Scale)), Scale: scale}
+	}
+	return Decimal{Unscaled: roundQuotient(d.Unscaled, pow10(d.Scale-scale), mode), Scale: scale}
+}
+
+func roundQuotient(num, den *big.Int, mode RoundingMode) *big.Int {
+	if den.Sign() < 0 {
+		num = new(big.Int).Neg(num)
+		den = new(big.Int).Neg(den)
+	}
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+	negative := num.Sign() < 0
+	half := new(big.Int).Abs(rem)
+	half.Mul(half, big.NewInt(2))
+	cmpHalf := half.Cmp(den)
+	awayFromZero := false
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		awayFromZero = true
+	case RoundFloor:
+		awayFromZero = negative
+	case RoundCeiling:
+		awayFromZero = !negative
+	case RoundHalfUp:
+		awayFromZero = cmpHalf >= 0
+	case RoundHalfDown:
+		awayFromZero = cmpHalf > 0
+	case RoundHalfEven:
+		awayFromZero = cmpHalf > 0 || (cmpHalf == 0 && q.Bit(0) == 1)
+	default:
+		raiseError("unknown rounding mode %s", mode)
+	}
+	if awayFromZero {
+		if negative {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.Unscaled).String()
+	if d.Scale > 0 {
+		if len(s) <= d.Scale {
+			s = strings.Repeat("0", d.Scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+	} else if d.Scale < 0 {
+		s += strings.Repeat("0", -d.Scale)
+	}
+	if d.Unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// normalized strips trailing fractional zeros so that equal values share
+// one representation.
+func (d Decimal) normalized() Decimal {
+	return d.trimZeros(0)
+}
+
+func (d Decimal) trimZeros(minScale int) Decimal {
+	n := new(big.Int).Set(d.Unscaled)
+	scale := d.Scale
+	rem := new(big.Int)
+	for scale > minScale && n.Sign() != 0 {
+		q, r := new(big.Int).QuoRem(n, bigTen, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		n = q
+		scale--
+	}
+	if n.Sign() == 0 {
+		scale = minScale
+	}
+	return Decimal{Unscaled: n, Scale: scale}
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) isInteger() bool {
+	return d.normalized().Scale <= 0
+}
+
+func parseDecimal(s string) (Decimal, bool) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Decimal{}, false
+	}
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return Decimal{}, false
+		}
+	}
+	n, ok := new(big.Int).SetString("0"+whole+frac, 10)
+	if !ok {
+		return Decimal{}, false
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return Decimal{Unscaled: n, Scale: len(frac)}, true
+}
+
+func toDecimal(v any) Decimal {
+	switch n := v.(type) {
+	case Decimal:
+		return n
+	case int:
+		return Decimal{Unscaled: big.NewInt(int64(n)), Scale: 0}
+	case *big.Int:
+		return Decimal{Unscaled: new(big.Int).Set(n), Scale: 0}
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			raiseError("cannot convert %v to decimal", n)
+		}
+		d, _ := parseDecimal(strconv.FormatFloat(n, 'f', -1, 64))
+		return d
+	case string:
+		d, ok := parseDecimal(n)
+		if !ok {
+			raiseError("cannot convert %q to decimal", n)
+		}
+		return d
+	}
+	raiseError("cannot convert %s to decimal", TypeName(v))
+	return Decimal{}
+}
+
+func compareNumbers(l, r any) int {
+	switch lv := l.(type) {
+	case int:
+		return compareOrdered(lv, r.(int))
+	case *big.Int:
+		return lv.Cmp(r.(*big.Int))
+	case float64:
+		return compareOrdered(lv, r.(float64))
+	case Decimal:
+		return lv.Cmp(r.(Decimal))
+	}
+	return 0
+}
+
+func roundingArg(name string, args []any, i int) RoundingMode {
+	if len(args) <= i {
+		return RoundHalfEven
+	}
+	return RoundingMode(stringArg(name, args, i))
+}
+
+func builtinDecimal(args []any) any {
+	checkArgCount("decimal", args, 1)
+	return toDecimal(args[0])
+}
+
+func builtinRound(args []any) any {
+	if len(args) < 1 || len(args) > 3 {
+		raiseError("round expects 1 to 3 arguments, got %d", len(args))
+	}
+	places := 0
+	if len(args) > 1 {
+		places = intArg("round", args, 1)
+	}
+	mode := roundingArg("round", args, 2)
+	switch v := args[0].(type) {
+	case int, *big.Int:
+		return v
+	case Decimal:
+		return v.Round(places, mode)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return v
+		}
+		return toDecimal(v).Round(places, mode).Float64()
+	}
+	raiseError("round expects a number, got %s", TypeName(args[0]))
+	return nil
+}
+
+var decimalMethods = map[string]methodFunc{
+	"div": func(recv any, args []any) any {
+		if len(args) < 2 || len(args) > 3 {
+			raiseError("div expects 2 or 3 arguments, got %d", len(args))
+		}
+		return recv.(Decimal).Div(toDecimal(args[0]), intArg("div", args, 1), roundingArg("div", args, 2))
+	},
+	"round": func(recv any, args []any) any {
+		return builtinRound(append([]any{recv}, args...))
+	},
+	"scale": func(recv any, args []any) any {
+		checkArgCount("scale", args, 0)
+		return recv.(Decimal).Scale
+	},
+}
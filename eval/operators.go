@@ -0,0 +1,31 @@
+package eval
+
+var operatorMethods = map[string]string{
+	"+":  "__add__",
+	"-":  "__sub__",
+	"*":  "__mul__",
+	"/":  "__div__",
+	"==": "__eq__",
+	"!=": "__ne__",
+	"<":  "__lt__",
+	"<=": "__le__",
+	">":  "__gt__",
+	">=": "__ge__",
+}
+
+func evalObjectInfix(obj *Object, r any, operator string) any {
+	if name, ok := operatorMethods[operator]; ok {
+		if m, ok := obj.method(name); ok {
+			return m.call([]any{r})
+		}
+	}
+	return evalBinary(obj, r, operator)
+}
+
+func boolResult(method string, v any) bool {
+	b, ok := v.(bool)
+	if !ok {
+		raiseError("%s must return a bool, got %s", method, TypeName(v))
+	}
+	return b
+}
@@ -0,0 +1,209 @@
+package eval
+
+import (
+	"bufio"
+	"errors"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Sandbox lists the host capabilities granted to scripts. An environment
+// without a sandbox has full access; the zero Sandbox grants none.
+type Sandbox struct {
+	Roots  []Root   // directories that import, lines and the file builtins may use
+	Env    []string // environment variables getenv may read
+	Exec   []string // programs exec may run, by name or path
+	Stdin  bool     // input may read standard input
+	Clock  bool     // now may read the wall clock
+	Random bool     // random may draw random numbers
+}
+
+// Root grants access to a directory tree, read-only unless Writable is set.
+type Root struct {
+	Path     string
+	Writable bool
+}
+
+var ErrNotPermitted = errors.New("not permitted")
+
+// PermissionError reports a builtin refused by the sandbox. It unwraps to
+// ErrNotPermitted.
+type PermissionError struct {
+	Op     string
+	Target string
+}
+
+func (e *PermissionError) Error() string {
+	if e.Target == "" {
+		return e.Op + ": " + ErrNotPermitted.Error()
+	}
+	return e.Op + ": access to " + e.Target + " " + ErrNotPermitted.Error()
+}
+
+func (e *PermissionError) Unwrap() error {
+	return ErrNotPermitted
+}
+
+// SetSandbox restricts the scripts run in env to the capabilities in sb.
+// A nil sandbox lifts all restrictions.
+func (env *Environment) SetSandbox(sb *Sandbox) {
+	env.state.sandbox = sb
+}
+
+func (s *runState) deny(op, target string) {
+	panic(&PermissionError{Op: op, Target: target})
+}
+
+func (s *runState) allow(op string, granted bool) {
+	if s.sandbox != nil && !granted {
+		s.deny(op, "")
+	}
+}
+
+func (s *runState) allowPath(op, path string, write bool) {
+	if s.sandbox == nil {
+		return
+	}
+	target, err := resolvePath(path)
+	if err == nil {
+		for _, root := range s.sandbox.Roots {
+			dir, err := resolvePath(root.Path)
+			if err != nil || (write && !root.Writable) {
+				continue
+			}
+			rel, err := filepath.Rel(dir, target)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return
+			}
+		}
+	}
+	s.deny(op, path)
+}
+
+// resolvePath makes path absolute and follows symlinks, so a link inside a
+// root cannot lead outside it. A missing final element is allowed so that
+// new files can be created.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real, nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
+
+// hostBuiltins reach outside the interpreter. They are bound to the run
+// state when looked up so they can consult its sandbox.
+var hostBuiltins = map[string]func(s *runState, args []any) any{
+	"lines":     builtinLines,
+	"readfile":  builtinReadFile,
+	"writefile": builtinWriteFile,
+	"getenv":    builtinGetenv,
+	"exec":      builtinExec,
+	"now":       builtinNow,
+	"random":    builtinRandom,
+}
+
+func builtinLines(s *runState, args []any) any {
+	checkArgCount("lines", args, 1)
+	path := stringArg("lines", args, 0)
+	s.allowPath("lines", path, false)
+	file, err := os.Open(path)
+	if err != nil {
+		raiseError("%s", err)
+	}
+	it := &lineIterator{file: file, scanner: bufio.NewScanner(file)}
+	s.open = append(s.open, it)
+	return &IteratorValue{it: it}
+}
+
+func builtinReadFile(s *runState, args []any) any {
+	checkArgCount("readfile", args, 1)
+	path := stringArg("readfile", args, 0)
+	s.allowPath("readfile", path, false)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		raiseError("%s", err)
+	}
+	return string(data)
+}
+
+func builtinWriteFile(s *runState, args []any) any {
+	checkArgCount("writefile", args, 2)
+	path := stringArg("writefile", args, 0)
+	s.allowPath("writefile", path, true)
+	if err := os.WriteFile(path, []byte(stringArg("writefile", args, 1)), 0o644); err != nil {
+		raiseError("%s", err)
+	}
+	return nil
+}
+
+func builtinGetenv(s *runState, args []any) any {
+	checkArgCount("getenv", args, 1)
+	name := stringArg("getenv", args, 0)
+	if s.sandbox != nil && !slices.Contains(s.sandbox.Env, name) {
+		s.deny("getenv", name)
+	}
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// builtinExec runs a program without a shell and returns its standard
+// output. Its standard error goes to the interpreter's.
+func builtinExec(s *runState, args []any) any {
+	if len(args) == 0 {
+		raiseError("exec expects at least 1 argument, got 0")
+	}
+	argv := make([]string, len(args))
+	for i := range args {
+		argv[i] = stringArg("exec", args, i)
+	}
+	if s.sandbox != nil && !slices.Contains(s.sandbox.Exec, argv[0]) {
+		s.deny("exec", argv[0])
+	}
+	cmd := exec.CommandContext(s.ctx, argv[0], argv[1:]...)
+	cmd.Stderr = s.stderr
+	out, err := cmd.Output()
+	if err != nil {
+		raiseError("exec: %v", err)
+	}
+	return string(out)
+}
+
+// builtinNow returns the current Unix time in seconds.
+func builtinNow(s *runState, args []any) any {
+	checkArgCount("now", args, 0)
+	s.allow("now", s.sandbox != nil && s.sandbox.Clock)
+	return float64(time.Now().UnixNano()) / 1e9
+}
+
+// builtinRandom returns a float in [0, 1), or an int in [0, n) when given n.
+func builtinRandom(s *runState, args []any) any {
+	s.allow("random", s.sandbox != nil && s.sandbox.Random)
+	switch len(args) {
+	case 0:
+		return rand.Float64()
+	case 1:
+		n := intArg("random", args, 0)
+		if n <= 0 {
+			raiseError("random expects a positive bound, got %d", n)
+		}
+		return rand.Intn(n)
+	}
+	raiseError("random expects at most 1 argument, got %d", len(args))
+	return nil
+}
@@ -0,0 +1,116 @@
+package eval
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// runState is shared by every environment descending from one global
+// environment.
+type runState struct {
+	ctx       context.Context
+	limits    Limits
+	steps     int
+	depth     int
+	allocated int
+	sandbox   *Sandbox
+	// open holds the generators started and files opened during the
+	// current run, so that those a script leaves unfinished are closed
+	// rather than leak their goroutines or file handles.
+	open   []closer
+	stdin  lineReader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type closer interface {
+	Close()
+}
+
+type lineReader interface {
+	ReadString(delim byte) (string, error)
+}
+
+// stdinReader buffers os.Stdin for every interpreter reading it. A reader
+// per interpreter would strand input that one buffered and never consumed.
+var stdinReader = &lockedReader{r: bufio.NewReader(os.Stdin)}
+
+type lockedReader struct {
+	mu sync.Mutex
+	r  *bufio.Reader
+}
+
+func (l *lockedReader) ReadString(delim byte) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.ReadString(delim)
+}
+
+func newRunState() *runState {
+	return &runState{
+		ctx:    context.Background(),
+		stdin:  stdinReader,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// SetStdin makes input read from r. Input is buffered, so r should not be
+// read by anything else while scripts run. Environments reading os.Stdin
+// share one buffer.
+func (env *Environment) SetStdin(r io.Reader) {
+	if r == os.Stdin {
+		env.state.stdin = stdinReader
+		return
+	}
+	env.state.stdin = bufio.NewReader(r)
+}
+
+// SetStdout directs print and println to w.
+func (env *Environment) SetStdout(w io.Writer) {
+	env.state.stdout = w
+}
+
+// SetStderr directs the standard error of programs run by exec to w.
+func (env *Environment) SetStderr(w io.Writer) {
+	env.state.stderr = w
+}
+
+// closeOpen stops the generators a run left suspended and closes the files
+// it left open. Neither outlives the run that started it.
+func (s *runState) closeOpen() {
+	for _, c := range s.open {
+		c.Close()
+	}
+	s.open = nil
+}
+
+func (s *runState) reset(ctx context.Context) {
+	s.ctx = ctx
+	s.steps = 0
+	s.depth = 0
+	s.allocated = 0
+}
+
+// CancelledError is returned when a run is stopped by its context, either
+// by cancellation or by a deadline.
+type CancelledError struct {
+	Cause error
+}
+
+func (e *CancelledError) Error() string {
+	return "execution cancelled: " + e.Cause.Error()
+}
+
+func (e *CancelledError) Unwrap() error {
+	return e.Cause
+}
+
+func (env *Environment) checkCancelled() {
+	if err := env.state.ctx.Err(); err != nil {
+		panic(&CancelledError{Cause: err})
+	}
+}
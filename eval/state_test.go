@@ -0,0 +1,14 @@
+package eval
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnvironmentsShareStdin(t *testing.T) {
+	a, b := CreateEnvironment(nil), CreateEnvironment(nil)
+	b.SetStdin(os.Stdin)
+	if a.state.stdin != b.state.stdin {
+		t.Fatal("environments reading os.Stdin have separate buffers")
+	}
+}
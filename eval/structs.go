@@ -0,0 +1,99 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/basemax/goscript/ast"
+)
+
+type StructType struct {
+	Name   string
+	Fields []string
+}
+
+type StructInstance struct {
+	Type   *StructType
+	Values []any
+}
+
+type namedArgument struct {
+	Name  string
+	Value any
+}
+
+func evalNamedArg(n ast.NamedArg, env *Environment) any {
+	return namedArgument{Name: n.Name, Value: Eval(n.Value, env)}
+}
+
+func evalStructDecl(n ast.StructDecl, env *Environment) any {
+	seen := map[string]bool{}
+	for _, field := range n.Fields {
+		if seen[field] {
+			raiseError("struct %s declares field %s more than once", n.Name, field)
+		}
+		seen[field] = true
+	}
+	t := &StructType{Name: n.Name, Fields: n.Fields}
+	env.SetFunction(n.Name, t)
+	return nil
+}
+
+func (t *StructType) construct(args []any) *StructInstance {
+	values, missing := bindArguments(t.Name, "field", t.Fields, args)
+	if len(missing) > 0 {
+		raiseError("%s is missing %s", t.Name, strings.Join(missing, ", "))
+	}
+	return &StructInstance{Type: t, Values: values}
+}
+
+func (t *StructType) String() string {
+	return "struct " + t.Name
+}
+
+func (s *StructInstance) fieldIndex(name string) int {
+	i := indexOfString(s.Type.Fields, name)
+	if i < 0 {
+		raiseError("%s has no field %s", s.Type.Name, name)
+	}
+	return i
+}
+
+func (s *StructInstance) get(name string) any {
+	return s.Values[s.fieldIndex(name)]
+}
+
+func (s *StructInstance) set(name string, v any) {
+	s.Values[s.fieldIndex(name)] = v
+}
+
+func (s *StructInstance) equal(other *StructInstance, seen visits) bool {
+	if s.Type != other.Type {
+		return false
+	}
+	if s == other {
+		return true
+	}
+	key := [2]any{s, other}
+	seen.enter(key, s, "compare")
+	defer seen.leave(key)
+	for i, v := range s.Values {
+		if !equal(v, other.Values[i], seen) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *StructInstance) String() string {
+	return s.format(visits{})
+}
+
+func (s *StructInstance) format(seen visits) string {
+	seen.enter(s, s, "format")
+	defer seen.leave(s)
+	parts := make([]string, len(s.Values))
+	for i, v := range s.Values {
+		parts[i] = s.Type.Fields[i] + ": " + format(v, seen)
+	}
+	return s.Type.Name + "(" + strings.Join(parts, ", ") + ")"
+}
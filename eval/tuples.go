@@ -0,0 +1,41 @@
+package eval
+
+import "strings"
+
+// Tuple is an immutable sequence. Unlike arrays, tuples can be used as map
+// keys and compare equal element by element.
+type Tuple []any
+
+func (t Tuple) String() string {
+	return t.format(visits{})
+}
+
+func (t Tuple) format(seen visits) string {
+	parts := make([]string, len(t))
+	for i, v := range t {
+		parts[i] = format(v, seen)
+	}
+	if len(t) == 1 {
+		return "(" + parts[0] + ",)"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func builtinTuple(args []any) any {
+	return Tuple(append([]any{}, args...))
+}
+
+func builtinFreeze(args []any) any {
+	checkArgCount("freeze", args, 1)
+	switch t := args[0].(type) {
+	case Tuple:
+		return t
+	case *Array:
+		frozen := make(Tuple, len(t.Elements))
+		for i, v := range t.Elements {
+			frozen[i] = builtinFreeze([]any{v})
+		}
+		return frozen
+	}
+	return args[0]
+}
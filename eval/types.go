@@ -0,0 +1,167 @@
+package eval
+
+import "github.com/basemax/goscript/ast"
+
+func builtinType(args []any) any {
+	checkArgCount("type", args, 1)
+	return TypeName(args[0])
+}
+
+func evalIsExpr(n ast.IsExpr, env *Environment) any {
+	v := Eval(n.Value, env)
+	var name string
+	var t any
+	if ident, ok := n.Type.(ast.Ident); ok {
+		name = ident.Lexeme.Text
+		t = Eval(ident, env)
+	} else {
+		t = Eval(n.Type, env)
+		name, _ = t.(string)
+	}
+	if is, ok := isInstance(v, t); ok {
+		return is
+	}
+	if !ast.BuiltinTypes[name] {
+		if name == "" {
+			name = formatValue(t)
+		}
+		raiseError("%s is not a type", name)
+	}
+	return TypeName(v) == name
+}
+
+func isInstance(v, t any) (bool, bool) {
+	switch t := t.(type) {
+	case *StructType:
+		inst, ok := v.(*StructInstance)
+		return ok && inst.Type == t, true
+	case *Class:
+		obj, ok := v.(*Object)
+		return ok && obj.Class.inherits(t), true
+	case *Enum:
+		ev, ok := v.(*EnumValue)
+		return ok && ev.Variant.Enum == t, true
+	case *EnumVariant:
+		ev, ok := v.(*EnumValue)
+		return ok && ev.Variant == t, true
+	}
+	return false, false
+}
+
+// matchesType reports whether v satisfies the annotation t. Ints are
+// accepted where a float or decimal is expected, mirroring the operator
+// promotion rules.
+func matchesType(t *ast.TypeSpec, v any, env *Environment) bool {
+	if t == nil || t.Name == "any" || (v == nil && t.Optional) {
+		return true
+	}
+	switch t.Name {
+	case "array":
+		arr, ok := v.(*Array)
+		return ok && elementsMatch(t, arr.Elements, env)
+	case "tuple":
+		tup, ok := v.(Tuple)
+		if !ok || (len(t.Elems) > 0 && len(t.Elems) != len(tup)) {
+			return false
+		}
+		for i, el := range t.Elems {
+			if !matchesType(el, tup[i], env) {
+				return false
+			}
+		}
+		return true
+	case "map":
+		m, ok := v.(*Map)
+		if !ok || len(t.Elems) != 2 {
+			return ok
+		}
+		return elementsMatch(&ast.TypeSpec{Elems: t.Elems[:1]}, m.Keys(), env) &&
+			elementsMatch(&ast.TypeSpec{Elems: t.Elems[1:]}, m.Values(), env)
+	case "float", "decimal":
+		if TypeName(v) == "int" {
+			return true
+		}
+	}
+	if ast.BuiltinTypes[t.Name] {
+		return TypeName(v) == t.Name
+	}
+	if is, ok := isInstance(v, env.lookupType(t.Name)); ok {
+		return is
+	}
+	raiseError("unknown type %s", t.Name)
+	return false
+}
+
+func (env *Environment) lookupType(name string) any {
+	for e := env; e != nil; e = e.parent {
+		if t, ok := e.functions[name]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+func elementsMatch(t *ast.TypeSpec, values []any, env *Environment) bool {
+	if len(t.Elems) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if !matchesType(t.Elems[0], v, env) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkReturn(fn Function, v any) any {
+	if !matchesType(fn.ReturnType, v, fn.Env) {
+		raiseError("%s must return %s, got %s", fn.Name, fn.ReturnType, TypeName(v))
+	}
+	return v
+}
+
+func evalLetStmt(n ast.LetStmt, env *Environment) any {
+	var v any
+	if n.Value != nil {
+		v = Eval(n.Value, env)
+	}
+	if !matchesType(n.Type, v, env) {
+		raiseError("cannot assign %s to %s of type %s", TypeName(v), n.Name.Lexeme.Text, n.Type)
+	}
+	env.SetVariable(*n.Name, v)
+	return nil
+}
+
+func functionInfo(fn any, field string) (any, bool) {
+	var name string
+	var params []*ast.Ident
+	switch f := fn.(type) {
+	case Function:
+		name, params = f.Name, f.Params
+	case BoundMethod:
+		name, params = f.Method.Name, f.Method.Params[1:]
+	case Builtin:
+		name = f.Name
+	default:
+		return nil, false
+	}
+	switch field {
+	case "name":
+		return name, true
+	case "params":
+		ret := []any{}
+		for _, p := range params {
+			ret = append(ret, p.Lexeme.Text)
+		}
+		return NewArray(ret), true
+	case "arity":
+		if _, ok := fn.(Builtin); ok {
+			return -1, true
+		}
+		return len(params), true
+	case "generator":
+		f, ok := fn.(Function)
+		return ok && f.IsGenerator, true
+	}
+	return nil, false
+}
@@ -0,0 +1,430 @@
+package goscript
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basemax/goscript/eval"
+	"github.com/basemax/goscript/parser"
+)
+
+// TestFeatures runs a small script for each language feature and compares
+// the value of its last expression.
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"slice array", "[1, 2, 3, 4, 5][1:3]", "[2 3]"},
+		{"slice step", "[1, 2, 3, 4, 5][::2]", "[1 3 5]"},
+		{"negative index", "[1, 2, 3][-1]", "3"},
+		{"slice string", `"hello"[1:3]`, "el"},
+		{"reverse slice", `"abc"[::-1]`, "cba"},
+
+		{"unicode index", `"héllo"[1]`, "é"},
+		{"unicode length", `len("héllo")`, "5"},
+		{"runes and bytes", `[runes("hé"), bytes("hé")]`, "[[104 233] [104 195 169]]"},
+		{"graphemes", "graphemes(\"e\u0301x\")", "[e\u0301 x]"},
+
+		{"map dot access", `m = {"a": 1}
+m.b = 2
+m.a + m.b`, "3"},
+		{"map get default", `{"a": 1}.get("z", 9)`, "9"},
+		{"string methods", `"a,b".split(",")`, "[a b]"},
+		{"array methods", `xs = [3, 1]
+xs.push(2)
+[xs.pop(), xs, xs.contains(1), xs.indexOf(1)]`, "[2 [3 1] true 1]"},
+		{"array aliasing", `a = [1, 2]
+b = a
+a.push(3)
+b`, "[1 2 3]"},
+
+		{"struct", `struct Point { x, y }
+p = Point(1, y: 2)
+p.x = 5
+[p, p.y]`, "[Point(x: 5, y: 2) 2]"},
+
+		{"class inheritance", `class Animal {
+  fn init(self, name) { self.name = name }
+  fn speak(self) { return self.name + " speaks" }
+}
+class Dog(Animal) {
+  fn speak(self) { return super.speak() + " woof" }
+}
+Dog("rex").speak()`, "rex speaks woof"},
+
+		{"enum match", `enum Shape { Circle(r), Square(s), Empty }
+fn area(s) {
+  return match s {
+    Shape.Circle(r) => r * r * 3
+    Shape.Square(x) => x * x
+    Shape.Empty => 0
+  }
+}
+[area(Shape.Circle(2)), area(Shape.Square(3)), area(Shape.Empty)]`, "[12 9 0]"},
+		{"match literals and wildcard", `fn f(x) {
+  return match x {
+    [a, b] => a + b
+    1 => "one"
+    _ => "other"
+  }
+}
+[f(1), f([2, 3]), f("z")]`, "[one 5 other]"},
+		{"match bindings scoped", `x = 1
+match 2 { x => x }
+x`, "1"},
+
+		{"operator overloading", `class V {
+  fn init(self, x) { self.x = x }
+  fn __add__(self, o) { return V(self.x + o.x) }
+  fn __eq__(self, o) { return self.x == o.x }
+  fn __str__(self) { return "V" + self.x }
+}
+[V(1) + V(2), V(1) == V(1)]`, "[V3 true]"},
+
+		{"for over generator", `fn gen() { yield 1
+  yield 2 }
+total = 0
+for v in gen() { total = total + v }
+total`, "3"},
+		{"break", `n = 0
+for i in 0..10 { if i == 3 { break }
+  n = n + 1 }
+n`, "3"},
+
+		{"generator", `fn gen(n) { for i in 0..n { yield i * 2 } }
+g = gen(2)
+[g.next(), g.next(), g.next(), g.next() == stop]`, "[0 2 4 true]"},
+		{"generator method", `class Bag {
+  fn init(self, xs) { self.xs = xs }
+  fn iter(self) { for x in self.xs { yield x * 2 } }
+}
+class Sub(Bag) {
+  fn iter(self) { for x in super.iter() { yield x + 1 } }
+}
+total = 0
+for v in Sub([1, 2]).iter() { total = total + v }
+total`, "8"},
+		{"iterator", `it = iter([7, 8])
+[it.next(), it.next(), it.done()]`, "[7 8 false]"},
+		{"class iterator", `class Count {
+  fn init(self, n) { self.i = 0
+    self.n = n }
+  fn next(self) {
+    if self.i == self.n { return stop }
+    self.i = self.i + 1
+    return self.i
+  }
+}
+total = 0
+for v in Count(4) { total = total + v }
+total`, "10"},
+
+		{"map order", `m = {"b": 1, "a": 2}
+m["c"] = 3
+[m.keys(), m.sorted()]`, "[[b a c] map[a:2 b:1 c:3]]"},
+
+		{"structural equality", `[[1, [2]] == [1, [2]], {"a": [1]} == {"a": [1]}, (1, 2) == (1, 2)]`, "[true true true]"},
+		{"tuple keys", `t = (1, "a")
+{t: 1}[(1, "a")]`, "1"},
+		{"brackets start a statement", `fn id(x) { return x }
+f = id
+(1, 2)
+[f, 3][1]`, "3"},
+
+		{"integer division", "1 / 3", "0"},
+		{"float promotion", "1.0 / 4", "0.25"},
+		{"conversions", `[int("42"), float("1.5"), str(3), bool("true")]`, "[42 1.5 3 true]"},
+
+		{"big integers", "9223372036854775807 + 1", "9223372036854775808"},
+		{"decimals", "0.1d + 0.2d", "0.3"},
+		{"decimal rounding", "round(2.345d, 2)", "2.34"},
+
+		{"type", `[type(1), type(1.5), type("s"), type([1]), type((1,)), type({})]`, "[int float string array tuple map]"},
+		{"is", `struct P { x }
+[P(1) is P, 1 is int, "s" is int]`, "[true true false]"},
+
+		{"annotations", `fn add(a: int, b: int) -> int { return a + b }
+let xs: [int] = [add(1, 2)]
+xs`, "[3]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New().Run(context.Background(), tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(v); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"len of int", "len(5)", "len of int"},
+		{"unknown struct field", "struct P { x }\nP(1).y", "P has no field y"},
+
+		{"missing enum field", "enum E { A(x) }\nE.A()", "E.A is missing x"},
+		{"no match arm", "match 3 { 1 => 1 }", "no match arm for 3"},
+
+		{"cannot iterate", "for x in 1 { }", "cannot iterate over int"},
+
+		{"not a type", "1 is 2", "is not a type"},
+
+		{"annotation mismatch", `let x: int = "s"`, "cannot assign string to x of type int"},
+		{"return annotation", `fn f() -> int { return "s" }
+f()`, "f must return int, got string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Run(context.Background(), tt.src)
+			var rerr *eval.RuntimeError
+			if !errors.As(err, &rerr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want a runtime error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedding(t *testing.T) {
+	in := New()
+	in.Set("base", 10)
+	in.RegisterFunc("twice", func(args []Value) (Value, error) {
+		return args[0].(int) * 2, nil
+	})
+	if err := in.RegisterGoFunc("join", strings.Join); err != nil {
+		t.Fatal(err)
+	}
+	v, err := in.Run(context.Background(), `total = twice(base) + 1
+join(["a", "b"], "-") + str(total)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "a-b21" {
+		t.Errorf("got %v, want a-b21", v)
+	}
+	if got, _ := in.Get("total"); got != 21 {
+		t.Errorf("total = %v, want 21", got)
+	}
+	if v, err := in.Eval(context.Background(), "total * 2"); err != nil || v != 42 {
+		t.Errorf("Eval = %v, %v, want 42", v, err)
+	}
+}
+
+func TestConvertStructs(t *testing.T) {
+	type config struct {
+		Name  string   `gos:"name"`
+		Tags  []string `gos:"tags"`
+		Skip  int      `gos:"-"`
+		Count int
+	}
+	v, err := ToValue(config{Name: "svc", Tags: []string{"a"}, Skip: 7, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := New()
+	in.Set("c", v)
+	out, err := in.Run(context.Background(), `c.name = c.name + "!"
+c.tags.push("b")
+c`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got config
+	if err := FromValue(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := config{Name: "svc!", Tags: []string{"a", "b"}, Count: 2}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		// A negative step never reaches the end of an ascending range, so
+		// only cancellation stops this loop.
+		{"endless loop", "for i in 0..10:-1 { }"},
+		{"unbounded recursion", `fn f(n) {
+  for i in 0..1000 { }
+  return f(n + 1)
+}
+f(0)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			in := New(WithLimits(Limits{MaxCallDepth: 1 << 30}))
+			_, err := in.Run(ctx, tt.src)
+			var cerr *eval.CancelledError
+			if !errors.As(err, &cerr) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got %v, want a cancellation error", err)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		src    string
+		want   error
+	}{
+		{Limits{MaxSteps: 100}, "for i in 0..1000 { }", eval.ErrStepLimit},
+		{Limits{MaxCallDepth: 20}, "fn f(n) { return f(n + 1) }\nf(0)", eval.ErrCallDepth},
+		{Limits{MaxStringLen: 10}, `s = "x"
+for i in 0..20 { s = s + "x" }`, eval.ErrStringLimit},
+		{Limits{MaxArrayLen: 10}, "xs = []\nfor i in 0..20 { xs.push(i) }", eval.ErrArrayLimit},
+		{Limits{MaxStringLen: 1 << 20}, `s = "x".repeat(1000)
+for i in 0..3 { s = s.replace("x", s) }`, eval.ErrStringLimit},
+		{Limits{MaxMemory: 100000}, `s = "x".repeat(1000)
+s.replace("x", s)`, eval.ErrMemoryLimit},
+		{Limits{MaxStringLen: 1 << 20}, `xs = []
+for i in 0..1000 { xs.push("") }
+xs.join("-".repeat(10000))`, eval.ErrStringLimit},
+		{Limits{MaxArrayLen: 10}, `"abcdefghijkl".split("")`, eval.ErrArrayLimit},
+	}
+	for _, tt := range tests {
+		_, err := New(WithLimits(tt.limits)).Run(context.Background(), tt.src)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.limits, err, tt.want)
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	in := New(WithSandbox(Sandbox{Env: []string{"HOME"}}))
+	if _, err := in.Run(context.Background(), `getenv("HOME")`); err != nil {
+		t.Errorf("granted getenv: %v", err)
+	}
+	for _, src := range []string{`getenv("PATH")`, `readfile("/etc/passwd")`, "now()", `exec("true")`} {
+		_, err := in.Run(context.Background(), src)
+		if !errors.Is(err, eval.ErrNotPermitted) {
+			t.Errorf("%s: got %v, want a permission error", src, err)
+		}
+	}
+}
+
+func TestSandboxRoots(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	out := filepath.Join(dir, "out")
+	for _, d := range []string{data, out} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secret := filepath.Join(dir, "secret.txt")
+	for _, f := range []string{filepath.Join(data, "a.txt"), secret} {
+		if err := os.WriteFile(f, []byte("x\ny\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, filepath.Join(data, "link")); err != nil {
+		t.Fatal(err)
+	}
+	in := New(WithSandbox(Sandbox{Roots: []Root{{Path: data}, {Path: out, Writable: true}}}))
+
+	granted := []string{
+		fmt.Sprintf("readfile(%q)", filepath.Join(data, "a.txt")),
+		fmt.Sprintf("for l in lines(%q) { }", filepath.Join(data, "a.txt")),
+		fmt.Sprintf("writefile(%q, \"z\")", filepath.Join(out, "b.txt")),
+	}
+	for _, src := range granted {
+		if _, err := in.Run(context.Background(), src); err != nil {
+			t.Errorf("%s: %v", src, err)
+		}
+	}
+	denied := []string{
+		fmt.Sprintf("writefile(%q, \"z\")", filepath.Join(data, "b.txt")),
+		fmt.Sprintf("readfile(%q)", data+"/../secret.txt"),
+		fmt.Sprintf("readfile(%q)", filepath.Join(data, "link")),
+		fmt.Sprintf("lines(%q)", filepath.Join(data, "link")),
+	}
+	for _, src := range denied {
+		_, err := in.Run(context.Background(), src)
+		if !errors.Is(err, eval.ErrNotPermitted) {
+			t.Errorf("%s: got %v, want a permission error", src, err)
+		}
+	}
+}
+
+func TestStdio(t *testing.T) {
+	var out bytes.Buffer
+	in := New(WithStdin(strings.NewReader("world\n")), WithStdout(&out),
+		WithSandbox(Sandbox{Stdin: true}))
+	_, err := in.Run(context.Background(), `name = input("name? ")
+println("hello", name.trim())
+print(1, 2, "x", 3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name? hello world\n1 2x3"; out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+}
+
+func TestProgramRunsConcurrently(t *testing.T) {
+	p, err := Compile("fn f(n) { if n <= 1 { return 1 }\n return n * f(n - 1) }\nf(base)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(chan any, 8)
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			in := New()
+			in.Set("base", i)
+			v, err := in.RunProgram(context.Background(), p)
+			if err != nil {
+				v = err
+			}
+			results <- v
+		}(i)
+	}
+	seen := map[any]bool{}
+	for i := 0; i < 8; i++ {
+		seen[<-results] = true
+	}
+	for _, want := range []int{1, 2, 6, 24, 120, 720, 5040} {
+		if !seen[want] {
+			t.Errorf("missing result %d in %v", want, seen)
+		}
+	}
+}
+
+func TestParseBeforeRun(t *testing.T) {
+	var out bytes.Buffer
+	src := "println(\"side effect\")\nfn f( { }"
+	var syntaxErr *parser.SyntaxError
+	if _, err := New(WithStdout(&out)).Run(context.Background(), src); !errors.As(err, &syntaxErr) {
+		t.Fatalf("Run: got %v, want a syntax error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Run executed %q before reporting the syntax error", out.String())
+	}
+	out.Reset()
+	if _, err := New(WithStdout(&out)).RunStream(context.Background(), src); err == nil {
+		t.Fatal("RunStream accepted a syntax error")
+	}
+	if out.String() != "side effect\n" {
+		t.Errorf("RunStream printed %q, want the statement before the error", out.String())
+	}
+}
@@ -1,25 +1,169 @@
-package main
+// Package goscript embeds the GoScript interpreter in Go programs.
+package goscript
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"io"
+
+	"github.com/basemax/goscript/ast"
+	"github.com/basemax/goscript/check"
+	"github.com/basemax/goscript/eval"
+	"github.com/basemax/goscript/parser"
+)
+
+// Interpreter runs GoScript source. Globals set with Set, and variables and
+// functions declared by a program, persist across calls to Run and Eval.
+//
+// Interpreters share no mutable state, so separate instances may run in
+// parallel. A single instance must not be used by several goroutines at
+// once.
+type Interpreter struct {
+	env *eval.Environment
+}
+
+// Limits bounds the steps, call depth and memory a single Run may use.
+type Limits = eval.Limits
+
+// Option configures an Interpreter created with New.
+type Option func(*Interpreter)
+
+// WithLimits applies resource limits to every Run. Exceeding one makes Run
+// return a *eval.LimitError.
+func WithLimits(l Limits) Option {
+	return func(in *Interpreter) {
+		in.env.SetLimits(l)
+	}
+}
+
+// Sandbox lists the host capabilities granted to scripts.
+type (
+	Sandbox = eval.Sandbox
+	Root    = eval.Root
 )
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Printf("Usage: %s <file>\n", os.Args[0])
-		return
+// WithSandbox restricts scripts to the capabilities in sb: filesystem
+// roots, environment variables, programs, standard input, the clock and
+// randomness. A refused builtin makes Run return a *eval.PermissionError.
+func WithSandbox(sb Sandbox) Option {
+	return func(in *Interpreter) {
+		in.env.SetSandbox(&sb)
 	}
+}
 
-	fileContent, fileErr := os.ReadFile(os.Args[1])
-	if fileErr != nil {
-		log.Fatal(fileErr)
+// WithStdin makes input read from r instead of os.Stdin.
+func WithStdin(r io.Reader) Option {
+	return func(in *Interpreter) {
+		in.env.SetStdin(r)
 	}
-	src := string(fileContent)
+}
 
-	scn := CreateScanner(src)
-	astParser := CreateParser(scn.lexemes)
-	env := CreateEnvironment(nil)
-	EvaluateNodes(astParser.astNodes, env)
+// WithStdout sends print and println output to w instead of os.Stdout.
+func WithStdout(w io.Writer) Option {
+	return func(in *Interpreter) {
+		in.env.SetStdout(w)
+	}
+}
+
+// WithStderr sends the standard error of programs run by exec to w instead
+// of os.Stderr.
+func WithStderr(w io.Writer) Option {
+	return func(in *Interpreter) {
+		in.env.SetStderr(w)
+	}
+}
+
+// New creates an interpreter configured by opts. Without options it has
+// full access to the host and no limits beyond the default call depth.
+func New(opts ...Option) *Interpreter {
+	in := &Interpreter{env: eval.CreateEnvironment(nil)}
+	for _, opt := range opts {
+		opt(in)
+	}
+	return in
+}
+
+// Run parses and evaluates src, returning the value of the last statement
+// or of a top-level return. Nothing runs unless all of src parses.
+func (in *Interpreter) Run(ctx context.Context, src string) (any, error) {
+	p, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return in.RunProgram(ctx, p)
+}
+
+// RunStream evaluates each statement of src as soon as it is parsed, as
+// a REPL or a very large script may need. A syntax error stops the run,
+// but statements before it will already have run.
+func (in *Interpreter) RunStream(ctx context.Context, src string) (any, error) {
+	p := parser.CreateSourceParser(src)
+	result, err := eval.RunNodes(ctx, p.Nodes, in.env)
+	if err == nil {
+		err = p.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RunProgram evaluates a compiled program against the interpreter's
+// globals.
+func (in *Interpreter) RunProgram(ctx context.Context, p *Program) (any, error) {
+	return eval.RunProgram(ctx, p.nodes, in.env)
+}
+
+// Eval evaluates a single expression against the interpreter's globals.
+func (in *Interpreter) Eval(ctx context.Context, expr string) (any, error) {
+	return in.Run(ctx, expr)
+}
+
+// Set defines the global variable name as v converted by ToValue, so Go
+// slices, maps and structs can be used like script values. Values that
+// already are script values are stored as they are.
+func (in *Interpreter) Set(name string, v any) error {
+	sv, err := ToValue(v)
+	if err != nil {
+		return fmt.Errorf("set %s: %w", name, err)
+	}
+	in.env.Define(name, sv)
+	return nil
+}
+
+// Get returns the value of the global variable name and whether it is
+// defined. Use FromValue to turn it back into a Go value.
+func (in *Interpreter) Get(name string) (any, bool) {
+	return in.env.Lookup(name)
+}
+
+// Program is parsed source that can be run many times without parsing it
+// again. Running never modifies a Program, so it may be run concurrently
+// by any number of interpreters.
+type Program struct {
+	nodes []ast.Node
+}
+
+// Compile parses src into a Program. A syntax error is returned as a
+// *parser.SyntaxError.
+func Compile(src string) (*Program, error) {
+	nodes, err := parser.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{nodes: nodes}, nil
+}
+
+// Run runs p in a new interpreter configured by opts.
+func (p *Program) Run(ctx context.Context, opts ...Option) (any, error) {
+	return New(opts...).RunProgram(ctx, p)
+}
+
+// Check statically type checks src without running it.
+func Check(src string) []error {
+	nodes, err := parser.Parse(src)
+	if err != nil {
+		return []error{err}
+	}
+	return check.Check(nodes)
 }
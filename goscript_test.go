@@ -0,0 +1,319 @@
+package goscript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basemax/goscript/eval"
+	"github.com/basemax/goscript/parser"
+)
+
+// waitGoroutines waits for the goroutine count to drop to at most want,
+// since goroutines that have been told to stop take a moment to exit.
+func waitGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines running, want at most %d", runtime.NumGoroutine(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSuspendedGeneratorsDoNotLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		in := New()
+		_, err := in.Run(context.Background(), `fn g() { for i in 0..10 { yield i } }
+let x = g()
+x.next()`)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitGoroutines(t, before)
+}
+
+func TestCancelRecursiveGenerators(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		// Lift the depth limit so only the deadline can stop the recursion.
+		in := New(WithLimits(Limits{MaxCallDepth: 1 << 30}))
+		_, err := in.Run(ctx, `fn g() { yield g().next() }
+g().next()`)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("got %v, want a deadline error", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("recursive generators still running after the deadline")
+	}
+}
+
+func TestGeneratorCallDepth(t *testing.T) {
+	src := `fn g() { yield g().next() }
+g().next()`
+	for _, limits := range []Limits{{MaxCallDepth: 50}, {}} {
+		_, err := New(WithLimits(limits)).Run(context.Background(), src)
+		if !errors.Is(err, eval.ErrCallDepth) {
+			t.Errorf("limits %+v: got %v, want a call depth error", limits, err)
+		}
+	}
+}
+
+func TestGeneratorReentry(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"fn gen() { yield g.next() }\ng = gen()\ng.next()", "already running"},
+		{"fn gen() { g.close()\n yield 1 }\ng = gen()\ng.next()", "cannot close itself"},
+	}
+	for _, tt := range tests {
+		done := make(chan error, 1)
+		go func() {
+			_, err := New().Run(context.Background(), tt.src)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			var rerr *eval.RuntimeError
+			if !errors.As(err, &rerr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%q: got %v, want a runtime error containing %q", tt.src, err, tt.want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("%q: deadlocked", tt.src)
+		}
+	}
+}
+
+func TestLinesClosedWhenRunEnds(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot count open files:", err)
+	}
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("x\ny\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := len(fds)
+	in := New()
+	src := fmt.Sprintf("it = lines(%q)\nfirst = [lines(%q)]", path, path)
+	if _, err := in.Run(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	if fds, _ := os.ReadDir("/proc/self/fd"); len(fds) > before {
+		t.Errorf("%d files left open after the run", len(fds)-before)
+	}
+}
+
+func TestSetConvertsGoValues(t *testing.T) {
+	in := New()
+	for name, v := range map[string]any{
+		"xs": []any{1, "a"},
+		"m":  map[string]any{"a": 1},
+		"p":  struct{ X int }{X: 2},
+	} {
+		if err := in.Set(name, v); err != nil {
+			t.Fatalf("Set(%s): %v", name, err)
+		}
+	}
+	v, err := in.Run(context.Background(), "xs[1] + str(m.a + p.X)")
+	if err != nil || v != "a3" {
+		t.Errorf("got %v, %v, want a3", v, err)
+	}
+	if err := in.Set("ch", make(chan int)); err == nil {
+		t.Error("Set accepted a channel")
+	}
+}
+
+func TestArrayMutatorsShareArray(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"let a = [1, 2, 3]\nlet b = a\na.pop()\na.push(9)\nstr(a) + \" \" + str(b)", "[1 2 9] [1 2 9]"},
+		{"let g = [1]\nfn f() { g.push(2) }\nf()\nlen(g)", "2"},
+		{"let a = [1, 2]\nlet b = a[0:2]\nb.push(3)\nlen(a)", "2"},
+	}
+	for _, tt := range tests {
+		v, err := New().Run(context.Background(), tt.src)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.src, err)
+		}
+		if got := fmt.Sprint(v); got != tt.want {
+			t.Errorf("%q = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestConvertArrays(t *testing.T) {
+	in := New()
+	v, err := ToValue([]int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Set("xs", v)
+	out, err := in.Run(context.Background(), "xs.push(3)\nxs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	if err := FromValue(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("got %v, want [1 2 3]", got)
+	}
+	var plain any
+	if err := FromValue(out, &plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain.([]any); !ok {
+		t.Errorf("FromValue into any gave %T, want []any", plain)
+	}
+}
+
+func TestGoFuncPanicBecomesError(t *testing.T) {
+	in := New()
+	if err := in.RegisterGoFunc("boom", func(xs []int) int { return xs[3] }); err != nil {
+		t.Fatal(err)
+	}
+	in.RegisterFunc("twice", func(args []Value) (Value, error) {
+		return args[0].(int) * 2, nil
+	})
+	for _, tt := range []struct{ src, want string }{
+		{"boom([1])", "index out of range"},
+		{`twice("a")`, "interface conversion"},
+	} {
+		_, err := in.Run(context.Background(), tt.src)
+		var rerr *eval.RuntimeError
+		if !errors.As(err, &rerr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want a runtime error reporting the panic", tt.src, err)
+		}
+	}
+}
+
+func TestToValueCycles(t *testing.T) {
+	type node struct{ Next *node }
+	n := &node{}
+	n.Next = n
+	m := map[string]any{}
+	m["self"] = m
+	s := []any{nil}
+	s[0] = s
+	for _, v := range []any{n, m, s} {
+		if _, err := ToValue(v); err == nil || !strings.Contains(err.Error(), "cyclic") {
+			t.Errorf("ToValue(%T) = %v, want a cycle error", v, err)
+		}
+	}
+
+	shared := &node{}
+	if _, err := ToValue([]*node{shared, shared}); err != nil {
+		t.Errorf("shared pointer without a cycle: %v", err)
+	}
+}
+
+func TestCyclicValues(t *testing.T) {
+	for _, src := range []string{
+		"a = [1]\na.push(a)\nb = [1]\nb.push(b)\na == b",
+		"a = [1]\na.push(a)\nprint(a)",
+		"m = {}\nm.self = m\nstr(m)",
+		"struct P { x }\np = P(1)\np.x = [p]\nq = P(1)\nq.x = [q]\np == q",
+	} {
+		_, err := New().Run(context.Background(), src)
+		var rerr *eval.RuntimeError
+		if !errors.As(err, &rerr) || !strings.Contains(err.Error(), "cyclic") {
+			t.Errorf("%q: got %v, want a cycle error", src, err)
+		}
+	}
+
+	v, err := New().Run(context.Background(), "a = [1]\na.push(a)\n[a == a, [a, a] == [a, a]]")
+	if err != nil || fmt.Sprint(v) != "[true true]" {
+		t.Errorf("identical cyclic arrays: got %v, %v", v, err)
+	}
+	v, err = New().Run(context.Background(), "a = [1]\na.push(a)\na")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	if err := FromValue(v, &out); err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("FromValue = %v, want a cycle error", err)
+	}
+}
+
+func TestMatchBindingsStayInArm(t *testing.T) {
+	src := `let x = 10
+let total = 0
+let r = match (1, 2) {
+  (x, y) => { total = x + y
+    x }
+}
+str(r) + " " + str(x) + " " + str(total)`
+	v, err := New().Run(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "1 10 3" {
+		t.Errorf("got %v, want 1 10 3", v)
+	}
+}
+
+func TestStrErrorsPropagate(t *testing.T) {
+	src := `class Spin {
+  fn __str__(self) {
+    for i in 0..1000000 { }
+    return "spin"
+  }
+}
+println([Spin()])`
+	_, err := New(WithLimits(Limits{MaxSteps: 1000})).Run(context.Background(), src)
+	if !errors.Is(err, eval.ErrStepLimit) {
+		t.Fatalf("got %v, want a step limit error", err)
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	_, err := New().Run(context.Background(), "struct P { x, y }\nP(1)")
+	if err == nil || !strings.Contains(err.Error(), "P is missing y") {
+		t.Errorf("missing field: got %v", err)
+	}
+	var syntaxErr *parser.SyntaxError
+	if _, err := Compile("struct S { 1 2 }"); !errors.As(err, &syntaxErr) {
+		t.Errorf("numeric field names: got %v, want a syntax error", err)
+	}
+}
+
+func TestIteratorsStopWithSentinel(t *testing.T) {
+	src := `fn g() { yield nil }
+let x = g()
+let first = x.next()
+class Nils {
+  fn init(self) { self.i = 0 }
+  fn next(self) {
+    if self.i == 2 { return stop }
+    self.i = self.i + 1
+    return nil
+  }
+}
+let n = 0
+for v in Nils() { n = n + 1 }
+str(first == nil) + " " + str(x.next() == stop) + " " + str(n)`
+	v, err := New().Run(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "true true 2" {
+		t.Errorf("got %v, want true true 2", v)
+	}
+}
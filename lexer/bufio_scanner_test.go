@@ -0,0 +1,215 @@
+package lexer
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// bufioScanner is the scanner NextToken replaced: a goroutine reading runes
+// through a bufio.Reader and sending every lexeme on a channel. It is kept,
+// bugs and all, only as the baseline for BenchmarkBufioScanner.
+type bufioScanner struct {
+	Lexemes chan Lexeme
+	rdr     *bufio.Reader
+	line    int
+	start   int
+	last    rune
+}
+
+func createBufioScanner(src string) *bufioScanner {
+	src = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\r`, "\r").Replace(src)
+	rdr := bufio.NewReader(strings.NewReader(src))
+	s := &bufioScanner{
+		rdr:     rdr,
+		Lexemes: make(chan Lexeme, 256),
+		line:    1,
+	}
+	go s.scanTokens()
+	return s
+}
+
+func (s *bufioScanner) scanTokens() {
+	for {
+		s.start = s.line
+		r, err := s.read()
+		if err == io.EOF {
+			s.sendToken(END_OF_FILE, "")
+			close(s.Lexemes)
+			break
+		}
+		switch {
+		case r == '"':
+			s.scanString()
+		case unicode.IsDigit(r):
+			s.scanNumber(r)
+		case unicode.IsLetter(r) || r == '_':
+			s.scanIdentifier(r)
+		default:
+			s.scanSymbol(r)
+		}
+	}
+}
+
+// read returns the next rune, keeping track of the current line.
+func (s *bufioScanner) read() (rune, error) {
+	r, _, err := s.rdr.ReadRune()
+	if err != nil {
+		return r, err
+	}
+	if r == '\n' {
+		s.line++
+	}
+	s.last = r
+	return r, nil
+}
+
+func (s *bufioScanner) unread() {
+	s.rdr.UnreadRune()
+	if s.last == '\n' {
+		s.line--
+	}
+}
+
+func (s *bufioScanner) skipPast(delim byte) error {
+	text, err := s.rdr.ReadString(delim)
+	s.line += strings.Count(text, "\n")
+	return err
+}
+
+func (s *bufioScanner) scanMultiLineComment() {
+	for {
+		if s.skipPast('*') != nil {
+			s.sendToken(ERROR_T, "unterminated comment")
+			return
+		}
+		nextRune, err := s.read()
+		if err != nil {
+			s.sendToken(ERROR_T, "unterminated comment")
+			return
+		}
+		if nextRune == '/' {
+			break
+		}
+		s.unread()
+	}
+}
+
+func (s *bufioScanner) sendToken(kind TokKind, txt string) {
+	s.Lexemes <- Lexeme{Kind: kind, Text: txt, Line: s.start}
+}
+
+func (s *bufioScanner) scanString() {
+	var builder strings.Builder
+	for {
+		r, err := s.read()
+		if err == io.EOF {
+			s.sendToken(ERROR_T, "unterminated string")
+			return
+		}
+		if r == '"' {
+			current := builder.String()
+			if len(current) > 0 && current[len(current)-1] == '\\' {
+				builder.WriteRune(r)
+				continue
+			}
+			break
+		}
+		builder.WriteRune(r)
+	}
+	result := strings.ReplaceAll(builder.String(), `\"`, `"`)
+	s.sendToken(STRING_T, result)
+}
+
+func (s *bufioScanner) scanIdentifier(initial rune) {
+	var builder strings.Builder
+	builder.WriteRune(initial)
+	for {
+		r, err := s.read()
+		if err != nil {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			builder.WriteRune(r)
+		} else {
+			s.unread()
+			break
+		}
+	}
+	text := builder.String()
+	if kw, ok := reservedWords[text]; ok {
+		s.sendToken(kw, text)
+	} else {
+		s.sendToken(IDENTIFIER, text)
+	}
+}
+
+func (s *bufioScanner) scanNumber(initial rune) {
+	tokType := INTEGER_T
+	var builder strings.Builder
+	builder.WriteRune(initial)
+	for {
+		r, err := s.read()
+		if err != nil {
+			break
+		}
+		if r == '.' {
+			nextRune, err := s.read()
+			if err == nil && nextRune == '.' {
+				s.sendToken(tokType, builder.String())
+				s.sendToken(DOTDOT_SYM, "..")
+				return
+			} else if err == nil {
+				s.unread()
+			}
+			tokType = FLOAT_T
+			builder.WriteRune(r)
+		} else if unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		} else if r == 'd' && !s.identifierFollows() {
+			tokType = DECIMAL_T
+			break
+		} else {
+			s.unread()
+			break
+		}
+	}
+	s.sendToken(tokType, builder.String())
+}
+
+func (s *bufioScanner) identifierFollows() bool {
+	r, err := s.read()
+	if err != nil {
+		return false
+	}
+	s.unread()
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func (s *bufioScanner) scanSymbol(r rune) {
+	single := string(r)
+	if peek, err := s.rdr.Peek(1); err == nil {
+		double := single + string(peek)
+		if t, ok := symbolMap[double]; ok {
+			s.read()
+			s.sendToken(t, double)
+			return
+		}
+		if double == "//" {
+			s.skipPast('\n')
+			return
+		}
+		if double == "/*" {
+			s.read()
+			s.scanMultiLineComment()
+			return
+		}
+	}
+	if t, ok := symbolMap[single]; ok {
+		s.sendToken(t, single)
+	} else if !unicode.IsSpace(r) && r != ';' {
+		s.sendToken(ERROR_T, "unexpected character "+strconv.QuoteRune(r))
+	}
+}
@@ -0,0 +1,432 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokKind string
+
+type Lexeme struct {
+	Kind TokKind
+	Text string
+	Line int
+}
+
+// Scanner delivers a lexer's output over a channel, for callers written
+// against the channel-based API. A caller that stops reading before
+// END_OF_FILE must call Stop, or the scanning goroutine is left blocked.
+type Scanner struct {
+	Lexemes chan Lexeme
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// Lexer splits source into lexemes on demand.
+type Lexer struct {
+	src   []byte
+	pos   int
+	line  int
+	start int
+	names map[string]string
+}
+
+const (
+	END_OF_FILE   TokKind = "EOF"
+	OPEN_PAREN    TokKind = "("
+	CLOSE_PAREN   TokKind = ")"
+	OPEN_CURLY    TokKind = "{"
+	CLOSE_CURLY   TokKind = "}"
+	OPEN_BRACKET  TokKind = "["
+	CLOSE_BRACKET TokKind = "]"
+	COMMA_SYM     TokKind = ","
+	DOT_SYM       TokKind = "."
+	PLUS_SYM      TokKind = "+"
+	MINUS_SYM     TokKind = "-"
+	MULTIPLY_SYM  TokKind = "*"
+	DIVIDE_SYM    TokKind = "/"
+	COLON_SYM     TokKind = ":"
+	SEMICOLON_SYM TokKind = ";"
+	EXCLAMATION   TokKind = "!"
+	QUESTION_MARK TokKind = "?"
+	ASSIGN        TokKind = "="
+	EQ_OP         TokKind = "=="
+	NEQ_OP        TokKind = "!="
+	GREATER_THAN  TokKind = ">"
+	LESS_THAN     TokKind = "<"
+	GREATER_EQ    TokKind = ">="
+	LESS_EQ       TokKind = "<="
+	STRING_T      TokKind = "STRING"
+	INTEGER_T     TokKind = "INT"
+	FLOAT_T       TokKind = "FLOAT"
+	DECIMAL_T     TokKind = "DECIMAL"
+	IDENTIFIER    TokKind = "IDENT"
+	ERROR_T       TokKind = "ERROR"
+	TRUE_T        TokKind = "TRUE"
+	FALSE_T       TokKind = "FALSE"
+	IF_T          TokKind = "IF"
+	ELSE_T        TokKind = "ELSE"
+	FUNCTION_T    TokKind = "FN"
+	PRINT_T       TokKind = "PRINT"
+	PRINTLN_T     TokKind = "PRINTLN"
+	RETURN_T      TokKind = "RETURN"
+	FOR_T         TokKind = "FOR"
+	DOTDOT_SYM    TokKind = ".."
+	SWAP_T        TokKind = "SWAP"
+	INPUT_T       TokKind = "INPUT"
+	LENGTH_T      TokKind = "LEN"
+	IMPORT_T      TokKind = "IMPORT"
+	OR_T          TokKind = "OR"
+	AND_T         TokKind = "AND"
+	STRUCT_T      TokKind = "STRUCT"
+	CLASS_T       TokKind = "CLASS"
+	ENUM_T        TokKind = "ENUM"
+	MATCH_T       TokKind = "MATCH"
+	ARROW_SYM     TokKind = "=>"
+	YIELD_T       TokKind = "YIELD"
+	BREAK_T       TokKind = "BREAK"
+	IS_T          TokKind = "IS"
+	LET_T         TokKind = "LET"
+	RETURNS_SYM   TokKind = "->"
+)
+
+var reservedWords = map[string]TokKind{
+	"fn":      FUNCTION_T,
+	"print":   PRINT_T,
+	"println": PRINTLN_T,
+	"return":  RETURN_T,
+	"true":    TRUE_T,
+	"false":   FALSE_T,
+	"if":      IF_T,
+	"else":    ELSE_T,
+	"for":     FOR_T,
+	"swap":    SWAP_T,
+	"input":   INPUT_T,
+	"len":     LENGTH_T,
+	"import":  IMPORT_T,
+	"or":      OR_T,
+	"and":     AND_T,
+	"struct":  STRUCT_T,
+	"class":   CLASS_T,
+	"enum":    ENUM_T,
+	"match":   MATCH_T,
+	"yield":   YIELD_T,
+	"break":   BREAK_T,
+	"is":      IS_T,
+	"let":     LET_T,
+}
+
+var symbolMap = map[string]TokKind{
+	"(":  OPEN_PAREN,
+	")":  CLOSE_PAREN,
+	"{":  OPEN_CURLY,
+	"}":  CLOSE_CURLY,
+	"[":  OPEN_BRACKET,
+	"]":  CLOSE_BRACKET,
+	",":  COMMA_SYM,
+	"..": DOTDOT_SYM,
+	".":  DOT_SYM,
+	"+":  PLUS_SYM,
+	"-":  MINUS_SYM,
+	"->": RETURNS_SYM,
+	"*":  MULTIPLY_SYM,
+	"/":  DIVIDE_SYM,
+	":":  COLON_SYM,
+	"!":  EXCLAMATION,
+	"?":  QUESTION_MARK,
+	"=":  ASSIGN,
+	"==": EQ_OP,
+	"=>": ARROW_SYM,
+	"!=": NEQ_OP,
+	">":  GREATER_THAN,
+	">=": GREATER_EQ,
+	"<":  LESS_THAN,
+	"<=": LESS_EQ,
+}
+
+// keywordText maps each keyword kind back to its spelling, so keywords
+// need no allocation.
+var keywordText = map[TokKind]string{}
+
+func init() {
+	for text, kind := range reservedWords {
+		keywordText[kind] = text
+	}
+}
+
+// CreateScanner lexes src in a goroutine, sending every lexeme up to and
+// including END_OF_FILE on Lexemes before closing it.
+func CreateScanner(src string) *Scanner {
+	s := &Scanner{Lexemes: make(chan Lexeme, 256), stop: make(chan struct{})}
+	l := NewLexer([]byte(src))
+	go func() {
+		defer close(s.Lexemes)
+		for {
+			lex := l.NextToken()
+			select {
+			case s.Lexemes <- lex:
+			case <-s.stop:
+				return
+			}
+			if lex.Kind == END_OF_FILE {
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Stop ends scanning early and closes Lexemes once the goroutine exits.
+// It is safe to call more than once, and after the end of input.
+func (s *Scanner) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func NewLexer(src []byte) *Lexer {
+	return &Lexer{src: src, line: 1, names: make(map[string]string)}
+}
+
+// NextToken returns the next lexeme, or END_OF_FILE once src is exhausted.
+func (l *Lexer) NextToken() Lexeme {
+	for {
+		l.skipSpace()
+		l.start = l.line
+		if l.pos >= len(l.src) {
+			return l.token(END_OF_FILE, "")
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			return l.scanString()
+		case isDigit(c):
+			return l.scanNumber()
+		case c == '/' && l.peek(1) == '/':
+			l.skipLine()
+		case c == '/' && l.peek(1) == '*':
+			if !l.skipComment() {
+				return l.token(ERROR_T, "unterminated comment")
+			}
+		case c == '_' || isLetter(c) || c >= utf8.RuneSelf && l.letterRune():
+			return l.scanIdentifier()
+		default:
+			return l.scanSymbol()
+		}
+	}
+}
+
+func (l *Lexer) token(kind TokKind, text string) Lexeme {
+	return Lexeme{Kind: kind, Text: text, Line: l.start}
+}
+
+func (l *Lexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+// skipSpace skips whitespace and semicolons, which only separate
+// statements.
+func (l *Lexer) skipSpace() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+		case c == ' ' || c == '\t' || c == '\r' || c == ';':
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRune(l.src[l.pos:])
+			if !unicode.IsSpace(r) {
+				return
+			}
+			l.pos += size - 1
+		default:
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *Lexer) skipLine() {
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+func (l *Lexer) skipComment() bool {
+	l.pos += 2
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == '\n':
+			l.line++
+		case l.src[l.pos] == '*' && l.peek(1) == '/':
+			l.pos += 2
+			return true
+		}
+		l.pos++
+	}
+	return false
+}
+
+// scanString reads a string literal. \n, \t, \r and \" are escapes; any
+// other backslash is kept as written.
+func (l *Lexer) scanString() Lexeme {
+	l.pos++
+	start := l.pos
+	var b *strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			var text string
+			if b == nil {
+				text = string(l.src[start:l.pos])
+			} else {
+				b.Write(l.src[start:l.pos])
+				text = b.String()
+			}
+			l.pos++
+			return l.token(STRING_T, text)
+		case '\n':
+			l.line++
+		case '\\':
+			var esc byte
+			switch l.peek(1) {
+			case 'n':
+				esc = '\n'
+			case 't':
+				esc = '\t'
+			case 'r':
+				esc = '\r'
+			case '"':
+				esc = '"'
+			case '\\':
+				esc = '\\'
+			default:
+				l.pos++
+				continue
+			}
+			if b == nil {
+				b = &strings.Builder{}
+			}
+			b.Write(l.src[start:l.pos])
+			b.WriteByte(esc)
+			l.pos += 2
+			start = l.pos
+			continue
+		}
+		l.pos++
+	}
+	return l.token(ERROR_T, "unterminated string")
+}
+
+func (l *Lexer) scanIdentifier() Lexeme {
+	start := l.pos
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '_' || isLetter(c) || isDigit(c) {
+			l.pos++
+			continue
+		}
+		if c < utf8.RuneSelf {
+			break
+		}
+		r, size := utf8.DecodeRune(l.src[l.pos:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		l.pos += size
+	}
+	word := l.src[start:l.pos]
+	if kw, ok := reservedWords[string(word)]; ok {
+		return l.token(kw, keywordText[kw])
+	}
+	return l.token(IDENTIFIER, l.intern(word))
+}
+
+// scanNumber reads an integer, a float, or a decimal marked by a trailing
+// d. A number directly followed by .. is the start of a range, and one
+// followed by a dot and a name is a member access; a second fractional
+// part, as in 1.2.3, is an error.
+func (l *Lexer) scanNumber() Lexeme {
+	start := l.pos
+	kind := INTEGER_T
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isDigit(c):
+			l.pos++
+			continue
+		case c == '.' && kind == FLOAT_T && isDigit(l.peek(1)):
+			for l.pos < len(l.src) && (l.src[l.pos] == '.' || isDigit(l.src[l.pos])) {
+				l.pos++
+			}
+			return l.token(ERROR_T, "malformed number "+string(l.src[start:l.pos]))
+		case c == '.' && kind == INTEGER_T && l.peek(1) != '.':
+			kind = FLOAT_T
+			l.pos++
+			continue
+		case c == 'd' && !l.identAt(l.pos+1):
+			text := l.intern(l.src[start:l.pos])
+			l.pos++
+			return l.token(DECIMAL_T, text)
+		}
+		break
+	}
+	return l.token(kind, l.intern(l.src[start:l.pos]))
+}
+
+func (l *Lexer) identAt(i int) bool {
+	if i >= len(l.src) {
+		return false
+	}
+	c := l.src[i]
+	if c < utf8.RuneSelf {
+		return c == '_' || isLetter(c) || isDigit(c)
+	}
+	r, _ := utf8.DecodeRune(l.src[i:])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (l *Lexer) letterRune() bool {
+	r, _ := utf8.DecodeRune(l.src[l.pos:])
+	return unicode.IsLetter(r)
+}
+
+func (l *Lexer) scanSymbol() Lexeme {
+	if l.pos+1 < len(l.src) {
+		if kind, ok := symbolMap[string(l.src[l.pos:l.pos+2])]; ok {
+			l.pos += 2
+			return l.token(kind, string(kind))
+		}
+	}
+	if kind, ok := symbolMap[string(l.src[l.pos:l.pos+1])]; ok {
+		l.pos++
+		return l.token(kind, string(kind))
+	}
+	r, size := utf8.DecodeRune(l.src[l.pos:])
+	l.pos += size
+	return l.token(ERROR_T, "unexpected character "+strconv.QuoteRune(r))
+}
+
+// intern returns a shared string for b, so a name or number repeated
+// throughout src is only allocated once.
+func (l *Lexer) intern(b []byte) string {
+	if s, ok := l.names[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	l.names[s] = s
+	return s
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,174 @@
+package lexer
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextToken(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Lexeme
+	}{
+		{"escapes", `"a\tb\n\"q\"\\" "x\\"`, []Lexeme{
+			{STRING_T, "a\tb\n\"q\"\\", 1},
+			{STRING_T, "x\\", 1},
+		}},
+		{"unknown escape kept", `"\u00e9"`, []Lexeme{{STRING_T, `\u00e9`, 1}}},
+		{"multiline string", "\"a\nb\" c", []Lexeme{
+			{STRING_T, "a\nb", 1},
+			{IDENTIFIER, "c", 2},
+		}},
+		{"unterminated string", `"abc`, []Lexeme{{ERROR_T, "unterminated string", 1}}},
+		{"numbers", "12 3.5 0.25", []Lexeme{
+			{INTEGER_T, "12", 1},
+			{FLOAT_T, "3.5", 1},
+			{FLOAT_T, "0.25", 1},
+		}},
+		{"decimal suffix", "19.99d 3d", []Lexeme{
+			{DECIMAL_T, "19.99", 1},
+			{DECIMAL_T, "3", 1},
+		}},
+		{"malformed numbers", "1.2.3d", []Lexeme{{ERROR_T, "malformed number 1.2.3", 1}}},
+		{"float member access", "1.5.x", []Lexeme{
+			{FLOAT_T, "1.5", 1},
+			{DOT_SYM, ".", 1},
+			{IDENTIFIER, "x", 1},
+		}},
+		{"d starting a name", "3dx", []Lexeme{
+			{INTEGER_T, "3", 1},
+			{IDENTIFIER, "dx", 1},
+		}},
+		{"range", "1..5 a..b", []Lexeme{
+			{INTEGER_T, "1", 1},
+			{DOTDOT_SYM, "..", 1},
+			{INTEGER_T, "5", 1},
+			{IDENTIFIER, "a", 1},
+			{DOTDOT_SYM, "..", 1},
+			{IDENTIFIER, "b", 1},
+		}},
+		{"member access", "p.x", []Lexeme{
+			{IDENTIFIER, "p", 1},
+			{DOT_SYM, ".", 1},
+			{IDENTIFIER, "x", 1},
+		}},
+		{"comments", "a // one\n/* two\nthree */ b / c", []Lexeme{
+			{IDENTIFIER, "a", 1},
+			{IDENTIFIER, "b", 3},
+			{DIVIDE_SYM, "/", 3},
+			{IDENTIFIER, "c", 3},
+		}},
+		{"two-character symbols", "-> => == != <= >= =", []Lexeme{
+			{RETURNS_SYM, "->", 1},
+			{ARROW_SYM, "=>", 1},
+			{EQ_OP, "==", 1},
+			{NEQ_OP, "!=", 1},
+			{LESS_EQ, "<=", 1},
+			{GREATER_EQ, ">=", 1},
+			{ASSIGN, "=", 1},
+		}},
+		{"keywords and names", "fn café let", []Lexeme{
+			{FUNCTION_T, "fn", 1},
+			{IDENTIFIER, "café", 1},
+			{LET_T, "let", 1},
+		}},
+		{"line numbers", "a\n\nb\r\nc", []Lexeme{
+			{IDENTIFIER, "a", 1},
+			{IDENTIFIER, "b", 3},
+			{IDENTIFIER, "c", 4},
+		}},
+		{"unexpected character", "@", []Lexeme{{ERROR_T, "unexpected character '@'", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer([]byte(tt.src))
+			var got []Lexeme
+			for {
+				lex := l.NextToken()
+				if lex.Kind == END_OF_FILE {
+					break
+				}
+				got = append(got, lex)
+				if lex.Kind == ERROR_T {
+					break
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("lexeme %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScannerStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		s := CreateScanner(benchSrc)
+		<-s.Lexemes
+		s.Stop()
+		s.Stop()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d scanner goroutines still running", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+const benchSnippet = `struct Point { x, y }
+fn dist(p: Point, q: Point) -> float {
+    dx = p.x - q.x
+    dy = p.y - q.y
+    return float(dx * dx + dy * dy)
+}
+/* totals */
+total = 0
+for i, v in [1, 2, 3, 4, 5] {
+    if v >= 2 and v != 4 { total = total + v * 1.5 } // weighted
+}
+m = {"name": "goscript\tlang", "price": 19.99d}
+println(m["name"], dist(Point(1, 2), Point(4, 6)), total)
+`
+
+var benchSrc = strings.Repeat(benchSnippet, 200)
+
+// BenchmarkBufioScanner measures the scanner that NextToken replaced, for
+// comparison with BenchmarkNextToken and BenchmarkScanner.
+func BenchmarkBufioScanner(b *testing.B) {
+	b.SetBytes(int64(len(benchSrc)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for range createBufioScanner(benchSrc).Lexemes {
+		}
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	b.SetBytes(int64(len(benchSrc)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for range CreateScanner(benchSrc).Lexemes {
+		}
+	}
+}
+
+func BenchmarkNextToken(b *testing.B) {
+	src := []byte(benchSrc)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := NewLexer(src)
+		for l.NextToken().Kind != END_OF_FILE {
+		}
+	}
+}
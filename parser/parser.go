@@ -0,0 +1,823 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/basemax/goscript/ast"
+	"github.com/basemax/goscript/lexer"
+)
+
+const (
+	LOWEST_PREC = iota + 1
+	PREC_OR
+	PREC_AND
+	PREC_EQUALS
+	PREC_LESSGREATER
+	PREC_SUM
+	PREC_PRODUCT
+	PREC_PREFIX
+	PREC_CALL
+	PREC_INDEX
+	PREC_RANGE
+)
+
+var precedences = map[lexer.TokKind]int{
+	lexer.OR_T:         PREC_OR,
+	lexer.AND_T:        PREC_AND,
+	lexer.ASSIGN:       PREC_EQUALS,
+	lexer.EQ_OP:        PREC_EQUALS,
+	lexer.NEQ_OP:       PREC_EQUALS,
+	lexer.IS_T:         PREC_EQUALS,
+	lexer.LESS_THAN:    PREC_LESSGREATER,
+	lexer.GREATER_THAN: PREC_LESSGREATER,
+	lexer.LESS_EQ:      PREC_LESSGREATER,
+	lexer.GREATER_EQ:   PREC_LESSGREATER,
+	lexer.PLUS_SYM:     PREC_SUM,
+	lexer.MINUS_SYM:    PREC_SUM,
+	lexer.DIVIDE_SYM:   PREC_PRODUCT,
+	lexer.MULTIPLY_SYM: PREC_PRODUCT,
+	lexer.OPEN_PAREN:   PREC_CALL,
+	lexer.OPEN_BRACKET: PREC_INDEX,
+	lexer.DOT_SYM:      PREC_INDEX,
+	lexer.DOTDOT_SYM:   PREC_RANGE,
+}
+
+type prefixParseFunc func() ast.Node
+
+type infixParseFunc func(ast.Node) ast.Node
+
+// SyntaxError reports the first point at which a program failed to parse.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error on line %d: %s", e.Line, e.Msg)
+}
+
+type Parser struct {
+	Nodes         chan ast.Node
+	err           *SyntaxError
+	next          func() lexer.Lexeme
+	lexemes       chan lexer.Lexeme
+	curLex        *lexer.Lexeme
+	nxtLex        *lexer.Lexeme
+	prefixParsers map[lexer.TokKind]prefixParseFunc
+	infixParsers  map[lexer.TokKind]infixParseFunc
+	sawYield      bool
+}
+
+// CreateParser parses lexemes in a goroutine, sending each top-level
+// statement on Nodes as soon as it is complete.
+func CreateParser(lexemes chan lexer.Lexeme) *Parser {
+	a := newParser(func() lexer.Lexeme {
+		lex, ok := <-lexemes
+		if !ok {
+			return lexer.Lexeme{Kind: lexer.END_OF_FILE}
+		}
+		return lex
+	})
+	a.lexemes = lexemes
+	a.Nodes = make(chan ast.Node)
+	go a.processParsing()
+	return a
+}
+
+// CreateSourceParser is CreateParser for source text. It pulls lexemes
+// from a lexer as it needs them, without a scanner goroutine or a channel
+// send per lexeme.
+func CreateSourceParser(src string) *Parser {
+	a := newParser(lexer.NewLexer([]byte(src)).NextToken)
+	a.Nodes = make(chan ast.Node)
+	go a.processParsing()
+	return a
+}
+
+func newParser(next func() lexer.Lexeme) *Parser {
+	a := &Parser{
+		next:         next,
+		infixParsers: make(map[lexer.TokKind]infixParseFunc),
+	}
+
+	a.prefixParsers = map[lexer.TokKind]prefixParseFunc{
+		lexer.IDENTIFIER:   a.parseIdent,
+		lexer.STRING_T:     a.parseStr,
+		lexer.INTEGER_T:    a.parseInteger,
+		lexer.FLOAT_T:      a.parseFloating,
+		lexer.DECIMAL_T:    a.parseDecimal,
+		lexer.MINUS_SYM:    a.parsePrefixOperator,
+		lexer.EXCLAMATION:  a.parsePrefixOperator,
+		lexer.TRUE_T:       a.parseBool,
+		lexer.FALSE_T:      a.parseBool,
+		lexer.OPEN_PAREN:   a.parseGroup,
+		lexer.IF_T:         a.parseIf,
+		lexer.FUNCTION_T:   a.parseFunction,
+		lexer.LET_T:        a.parseLet,
+		lexer.PRINT_T:      a.parsePrint,
+		lexer.PRINTLN_T:    a.parsePrint,
+		lexer.OPEN_BRACKET: a.parseArray,
+		lexer.OPEN_CURLY:   a.parseMap,
+		lexer.FOR_T:        a.parseFor,
+		lexer.RETURN_T:     a.parseRet,
+		lexer.SWAP_T:       a.parseSwap,
+		lexer.INPUT_T:      a.parseInput,
+		lexer.LENGTH_T:     a.parseLen,
+		lexer.IMPORT_T:     a.parseImport,
+		lexer.STRUCT_T:     a.parseStruct,
+		lexer.CLASS_T:      a.parseClass,
+		lexer.ENUM_T:       a.parseEnum,
+		lexer.MATCH_T:      a.parseMatch,
+		lexer.YIELD_T:      a.parseYield,
+		lexer.BREAK_T:      a.parseBreak,
+	}
+
+	for _, kind := range []lexer.TokKind{lexer.OR_T, lexer.AND_T, lexer.PLUS_SYM, lexer.MINUS_SYM, lexer.MULTIPLY_SYM, lexer.DIVIDE_SYM, lexer.EQ_OP, lexer.NEQ_OP, lexer.GREATER_THAN, lexer.GREATER_EQ, lexer.LESS_THAN, lexer.LESS_EQ} {
+		a.infixParsers[kind] = a.parseInfixOperator
+	}
+
+	a.infixParsers[lexer.OPEN_PAREN] = a.parseCall
+	a.infixParsers[lexer.OPEN_BRACKET] = a.parseIndex
+	a.infixParsers[lexer.DOT_SYM] = a.parseMember
+	a.infixParsers[lexer.DOTDOT_SYM] = a.parseRange
+	a.infixParsers[lexer.ASSIGN] = a.parseVarAssign
+	a.infixParsers[lexer.IS_T] = a.parseIs
+
+	first, second := next(), next()
+	a.curLex, a.nxtLex = &first, &second
+	return a
+}
+
+// Parse parses a whole program, returning its statements only if all of
+// src is valid.
+func Parse(src string) ([]ast.Node, error) {
+	a := newParser(lexer.NewLexer([]byte(src)).NextToken)
+	var nodes []ast.Node
+	a.parseProgram(func(node ast.Node) {
+		nodes = append(nodes, node)
+	})
+	if err := a.Err(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Err returns the syntax error that stopped parsing, if any. It is only
+// meaningful once Nodes has been closed.
+func (a *Parser) Err() error {
+	if a.err == nil {
+		return nil
+	}
+	return a.err
+}
+
+func (a *Parser) fail(format string, args ...any) {
+	panic(&SyntaxError{Line: a.curLex.Line, Msg: fmt.Sprintf(format, args...)})
+}
+
+// expect advances to the next lexeme, which must be of the given kind.
+func (a *Parser) expect(kind lexer.TokKind) {
+	a.advance()
+	if a.curLex.Kind != kind {
+		want := string(kind)
+		if kind == lexer.IDENTIFIER {
+			want = "a name"
+		}
+		a.fail("expected %s, found %s", want, describe(a.curLex))
+	}
+}
+
+// before reports whether the next lexeme is not yet the closing kind of a
+// list, failing if the input ends first.
+func (a *Parser) before(kind lexer.TokKind) bool {
+	if a.nxtLex.Kind == lexer.END_OF_FILE {
+		a.advance()
+		a.fail("expected %s, found end of input", kind)
+	}
+	return a.nxtLex.Kind != kind
+}
+
+func describe(l *lexer.Lexeme) string {
+	switch l.Kind {
+	case lexer.END_OF_FILE:
+		return "end of input"
+	case lexer.STRING_T:
+		return strconv.Quote(l.Text)
+	}
+	return l.Text
+}
+
+func (a *Parser) getPrecedence(kind lexer.TokKind) int {
+	if prec, ok := precedences[kind]; ok {
+		return prec
+	}
+	return LOWEST_PREC
+}
+
+func (a *Parser) checkNext(expected lexer.TokKind) bool {
+	if a.nxtLex.Kind == expected {
+		a.advance()
+		return true
+	}
+	return false
+}
+
+func (a *Parser) advance() {
+	a.curLex = a.nxtLex
+	next := a.next()
+	a.nxtLex = &next
+}
+
+func (a *Parser) processParsing() {
+	defer close(a.Nodes)
+	a.parseProgram(func(node ast.Node) {
+		a.Nodes <- node
+	})
+	if a.err != nil && a.lexemes != nil {
+		for range a.lexemes {
+		}
+	}
+}
+
+// parseProgram passes each top-level statement to emit, stopping at the
+// end of input or the first syntax error.
+func (a *Parser) parseProgram(emit func(ast.Node)) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			a.err = err
+		}
+	}()
+	for a.curLex.Kind != lexer.END_OF_FILE {
+		emit(a.parseExpr(LOWEST_PREC))
+		a.advance()
+	}
+}
+
+func (a *Parser) parseExpr(prec int) ast.Node {
+	if a.curLex.Kind == lexer.ERROR_T {
+		a.fail("%s", a.curLex.Text)
+	}
+	prefix, ok := a.prefixParsers[a.curLex.Kind]
+	if !ok {
+		a.fail("unexpected %s", describe(a.curLex))
+	}
+	left := prefix()
+	nextPrec := a.getPrecedence(a.nxtLex.Kind)
+	for nextPrec > prec && !a.postfixOnNewLine() {
+		infix, ok := a.infixParsers[a.nxtLex.Kind]
+		if !ok {
+			return left
+		}
+		a.advance()
+		left = infix(left)
+		nextPrec = a.getPrecedence(a.nxtLex.Kind)
+	}
+	return left
+}
+
+// postfixOnNewLine reports whether the next lexeme is a ( or [ at the
+// start of a new line. It begins a new statement rather than calling or
+// indexing the expression before it.
+func (a *Parser) postfixOnNewLine() bool {
+	switch a.nxtLex.Kind {
+	case lexer.OPEN_PAREN, lexer.OPEN_BRACKET:
+		return a.nxtLex.Line > a.curLex.Line
+	}
+	return false
+}
+
+func (a *Parser) parseStr() ast.Node {
+	return ast.StringLiteral{Lexeme: *a.curLex, Value: a.curLex.Text}
+}
+
+func (a *Parser) parseIdent() ast.Node {
+	return ast.Ident{Lexeme: *a.curLex, IsFunc: a.nxtLex.Kind == lexer.OPEN_PAREN && !a.postfixOnNewLine()}
+}
+func (a *Parser) parseInteger() ast.Node {
+	v, err := strconv.Atoi(a.curLex.Text)
+	lit := ast.IntegerLiteral{
+		Lexeme: *a.curLex,
+		Value:  v,
+	}
+	if err != nil {
+		lit.Big, _ = new(big.Int).SetString(a.curLex.Text, 10)
+	}
+	return lit
+}
+
+func (a *Parser) parseDecimal() ast.Node {
+	return ast.DecimalLiteral{Lexeme: *a.curLex}
+}
+
+func (a *Parser) parseFloating() ast.Node {
+	v, _ := strconv.ParseFloat(a.curLex.Text, 64)
+	return ast.FloatLiteral{
+		Lexeme: *a.curLex,
+		Value:  v,
+	}
+}
+
+func (a *Parser) parseBool() ast.Node {
+	b, _ := strconv.ParseBool(a.curLex.Text)
+	return ast.BooleanLiteral{
+		Lexeme: *a.curLex,
+		Value:  b,
+	}
+}
+
+func (a *Parser) parseRet() ast.Node {
+	if a.nxtLex.Kind == lexer.CLOSE_CURLY {
+		return ast.ReturnStmt{}
+	}
+	a.advance()
+	return ast.ReturnStmt{
+		Expr: a.parseExpr(LOWEST_PREC),
+	}
+}
+
+func (a *Parser) parseVarAssign(left ast.Node) ast.Node {
+	a.advance()
+	assign := ast.VarAssign{
+		Name: left,
+	}
+	assign.Value = a.parseExpr(LOWEST_PREC)
+	return assign
+}
+
+func (a *Parser) parseGroup() ast.Node {
+	a.advance()
+	exp := a.parseExpr(LOWEST_PREC)
+	if a.nxtLex.Kind != lexer.COMMA_SYM {
+		a.expect(lexer.CLOSE_PAREN)
+		return exp
+	}
+	tuple := ast.TupleLiteral{Elements: []ast.Node{exp}}
+	for a.checkNext(lexer.COMMA_SYM) && a.before(lexer.CLOSE_PAREN) {
+		a.advance()
+		tuple.Elements = append(tuple.Elements, a.parseExpr(LOWEST_PREC))
+	}
+	a.expect(lexer.CLOSE_PAREN)
+	return tuple
+}
+
+func (a *Parser) parsePrefixOperator() ast.Node {
+	op := ast.PrefixOp{
+		Lexeme: *a.curLex,
+	}
+	a.advance()
+	op.Expr = a.parseExpr(PREC_PREFIX)
+	return op
+}
+
+func (a *Parser) parseInfixOperator(left ast.Node) ast.Node {
+	op := ast.InfixOp{
+		Lexeme: *a.curLex,
+		Left:   left,
+	}
+	prec := a.getPrecedence(a.curLex.Kind)
+	a.advance()
+	op.Right = a.parseExpr(prec)
+	return op
+}
+
+func (a *Parser) parseIs(left ast.Node) ast.Node {
+	a.advance()
+	return ast.IsExpr{
+		Value: left,
+		Type:  a.parseExpr(PREC_EQUALS),
+	}
+}
+
+func (a *Parser) parseBlock() *ast.BlockStmt {
+	block := &ast.BlockStmt{
+		Stmts: []ast.Node{},
+	}
+	for a.before(lexer.CLOSE_CURLY) {
+		a.advance()
+		exp := a.parseExpr(LOWEST_PREC)
+		block.Stmts = append(block.Stmts, exp)
+	}
+	a.advance()
+	return block
+}
+
+func (a *Parser) parseIf() ast.Node {
+	a.advance()
+	cond := a.parseExpr(LOWEST_PREC)
+	ifStmt := ast.IfStmt{
+		Condition: cond,
+	}
+	a.expect(lexer.OPEN_CURLY)
+	ifStmt.Then = a.parseBlock()
+	if !a.checkNext(lexer.ELSE_T) {
+		return ifStmt
+	}
+	a.expect(lexer.OPEN_CURLY)
+	ifStmt.Else = a.parseBlock()
+	return ifStmt
+}
+
+func (a *Parser) parseFor() ast.Node {
+	a.expect(lexer.IDENTIFIER)
+	keyIdent := a.parseIdent().(ast.Ident)
+	forStmt := ast.ForStmt{
+		Key: &keyIdent,
+	}
+	if a.checkNext(lexer.COMMA_SYM) {
+		a.expect(lexer.IDENTIFIER)
+		valIdent := a.parseIdent().(ast.Ident)
+		forStmt.Value = &valIdent
+	}
+	a.advance()
+	if a.curLex.Text != "in" || a.curLex.Kind != lexer.IDENTIFIER {
+		a.fail("expected in, found %s", describe(a.curLex))
+	}
+	a.advance()
+	forStmt.Target = a.parseExpr(LOWEST_PREC)
+	a.expect(lexer.OPEN_CURLY)
+	forStmt.Body = a.parseBlock()
+	return forStmt
+}
+
+func (a *Parser) parseRange(left ast.Node) ast.Node {
+	rnge := ast.RangeExpr{
+		From: left,
+	}
+	a.advance()
+	rnge.To = a.parseExpr(LOWEST_PREC)
+	if a.checkNext(lexer.COLON_SYM) {
+		a.advance()
+		rnge.Step = a.parseExpr(LOWEST_PREC)
+	}
+	return rnge
+}
+
+func (a *Parser) parsePrint() ast.Node {
+	ps := ast.PrintStmt{
+		Args:    []ast.Node{},
+		NewLine: a.curLex.Kind == lexer.PRINTLN_T,
+	}
+	a.advance()
+	ps.Args = a.parseArgList()
+	return ps
+}
+
+func (a *Parser) parseArgList() []ast.Node {
+	args := []ast.Node{}
+	for a.before(lexer.CLOSE_PAREN) {
+		a.advance()
+		if a.curLex.Kind == lexer.COMMA_SYM {
+			a.advance()
+		}
+		args = append(args, a.parseArg())
+	}
+	a.advance()
+	return args
+}
+
+func (a *Parser) parseArg() ast.Node {
+	if a.curLex.Kind == lexer.IDENTIFIER && a.nxtLex.Kind == lexer.COLON_SYM {
+		name := a.curLex.Text
+		a.advance()
+		a.advance()
+		return ast.NamedArg{
+			Name:  name,
+			Value: a.parseExpr(LOWEST_PREC),
+		}
+	}
+	return a.parseExpr(LOWEST_PREC)
+}
+
+func (a *Parser) parseArray() ast.Node {
+	a.advance()
+	arr := ast.ArrayLiteral{
+		Elements: make([]ast.Node, 0),
+	}
+	for a.curLex.Kind != lexer.CLOSE_BRACKET {
+		arr.Elements = append(arr.Elements, a.parseExpr(LOWEST_PREC))
+		a.advance()
+		switch a.curLex.Kind {
+		case lexer.COMMA_SYM:
+			a.advance()
+		case lexer.CLOSE_BRACKET:
+		default:
+			a.fail("expected , or ], found %s", describe(a.curLex))
+		}
+	}
+	return arr
+}
+
+func (a *Parser) parseIndex(left ast.Node) ast.Node {
+	a.advance()
+	var index ast.Node
+	if a.curLex.Kind != lexer.COLON_SYM {
+		index = a.parseExpr(LOWEST_PREC)
+		if !a.checkNext(lexer.COLON_SYM) {
+			a.expect(lexer.CLOSE_BRACKET)
+			return ast.IndexExpr{
+				Collection: left,
+				Index:      index,
+			}
+		}
+	}
+	slice := ast.SliceExpr{
+		Collection: left,
+		Start:      index,
+	}
+	slice.End = a.parseSliceBound()
+	if a.checkNext(lexer.COLON_SYM) {
+		slice.Step = a.parseSliceBound()
+	}
+	a.expect(lexer.CLOSE_BRACKET)
+	return slice
+}
+
+func (a *Parser) parseSliceBound() ast.Node {
+	if a.nxtLex.Kind == lexer.COLON_SYM || a.nxtLex.Kind == lexer.CLOSE_BRACKET {
+		return nil
+	}
+	a.advance()
+	return a.parseExpr(LOWEST_PREC)
+}
+
+func (a *Parser) parseMember(left ast.Node) ast.Node {
+	a.advance()
+	if a.curLex.Text == "" || a.curLex.Kind == lexer.STRING_T {
+		a.fail("expected a member name, found %s", describe(a.curLex))
+	}
+	name := a.curLex.Text
+	if a.checkNext(lexer.OPEN_PAREN) {
+		return ast.MethodCall{
+			Object: left,
+			Name:   name,
+			Args:   a.parseArgList(),
+		}
+	}
+	return ast.MemberExpr{
+		Object: left,
+		Name:   name,
+	}
+}
+
+func (a *Parser) parseMap() ast.Node {
+	a.advance()
+	m := ast.MapLiteral{Pairs: []ast.MapPair{}}
+	if a.curLex.Kind == lexer.CLOSE_CURLY {
+		return m
+	}
+	for {
+		key := a.parseExpr(LOWEST_PREC)
+		a.expect(lexer.COLON_SYM)
+		a.advance()
+		val := a.parseExpr(LOWEST_PREC)
+		m.Pairs = append(m.Pairs, ast.MapPair{Key: key, Value: val})
+
+		if a.nxtLex.Kind == lexer.COMMA_SYM {
+			a.advance()
+			a.advance()
+		}
+
+		if !a.before(lexer.CLOSE_CURLY) {
+			a.advance()
+			break
+		}
+	}
+	return m
+}
+
+func (a *Parser) parseFunction() ast.Node {
+	a.expect(lexer.IDENTIFIER)
+	fn := ast.FunctionLiteral{Name: a.curLex.Text}
+	a.expect(lexer.OPEN_PAREN)
+	fn.Params, fn.ParamTypes = a.parseParamList()
+	if a.checkNext(lexer.RETURNS_SYM) {
+		a.advance()
+		fn.ReturnType = a.parseTypeSpec()
+	}
+	a.expect(lexer.OPEN_CURLY)
+	outerYield := a.sawYield
+	a.sawYield = false
+	fn.Body = a.parseBlock()
+	fn.IsGenerator = a.sawYield
+	a.sawYield = outerYield
+	return fn
+}
+
+func (a *Parser) parseYield() ast.Node {
+	a.sawYield = true
+	a.advance()
+	return ast.YieldStmt{
+		Expr: a.parseExpr(LOWEST_PREC),
+	}
+}
+
+func (a *Parser) parseBreak() ast.Node {
+	return ast.BreakStmt{}
+}
+
+func (a *Parser) parseParamList() ([]*ast.Ident, []*ast.TypeSpec) {
+	params := []*ast.Ident{}
+	types := []*ast.TypeSpec{}
+	for a.before(lexer.CLOSE_PAREN) {
+		a.advance()
+		if a.curLex.Kind == lexer.COMMA_SYM {
+			a.advance()
+		}
+		if a.curLex.Kind != lexer.IDENTIFIER {
+			a.fail("expected a parameter name, found %s", describe(a.curLex))
+		}
+		params = append(params, &ast.Ident{Lexeme: *a.curLex})
+		var t *ast.TypeSpec
+		if a.checkNext(lexer.COLON_SYM) {
+			a.advance()
+			t = a.parseTypeSpec()
+		}
+		types = append(types, t)
+	}
+	a.advance()
+	return params, types
+}
+
+func (a *Parser) parseTypeSpec() *ast.TypeSpec {
+	var t *ast.TypeSpec
+	switch a.curLex.Kind {
+	case lexer.OPEN_BRACKET:
+		a.advance()
+		t = &ast.TypeSpec{Name: "array", Elems: []*ast.TypeSpec{a.parseTypeSpec()}}
+		a.expect(lexer.CLOSE_BRACKET)
+	case lexer.OPEN_CURLY:
+		a.advance()
+		key := a.parseTypeSpec()
+		a.expect(lexer.COLON_SYM)
+		a.advance()
+		t = &ast.TypeSpec{Name: "map", Elems: []*ast.TypeSpec{key, a.parseTypeSpec()}}
+		a.expect(lexer.CLOSE_CURLY)
+	case lexer.OPEN_PAREN:
+		t = &ast.TypeSpec{Name: "tuple"}
+		for a.before(lexer.CLOSE_PAREN) {
+			a.advance()
+			if a.curLex.Kind == lexer.COMMA_SYM {
+				continue
+			}
+			t.Elems = append(t.Elems, a.parseTypeSpec())
+		}
+		a.advance()
+	case lexer.IDENTIFIER:
+		t = &ast.TypeSpec{Name: a.curLex.Text}
+	default:
+		a.fail("expected a type, found %s", describe(a.curLex))
+	}
+	if a.checkNext(lexer.QUESTION_MARK) {
+		t.Optional = true
+	}
+	return t
+}
+
+func (a *Parser) parseLet() ast.Node {
+	a.expect(lexer.IDENTIFIER)
+	let := ast.LetStmt{Name: &ast.Ident{Lexeme: *a.curLex}}
+	if a.checkNext(lexer.COLON_SYM) {
+		a.advance()
+		let.Type = a.parseTypeSpec()
+	}
+	if a.checkNext(lexer.ASSIGN) {
+		a.advance()
+		let.Value = a.parseExpr(LOWEST_PREC)
+	}
+	return let
+}
+
+func (a *Parser) parseStruct() ast.Node {
+	a.expect(lexer.IDENTIFIER)
+	decl := ast.StructDecl{Name: a.curLex.Text}
+	a.expect(lexer.OPEN_CURLY)
+	for a.before(lexer.CLOSE_CURLY) {
+		if a.checkNext(lexer.COMMA_SYM) {
+			continue
+		}
+		a.expect(lexer.IDENTIFIER)
+		decl.Fields = append(decl.Fields, a.curLex.Text)
+	}
+	a.advance()
+	return decl
+}
+
+func (a *Parser) parseClass() ast.Node {
+	a.expect(lexer.IDENTIFIER)
+	decl := ast.ClassDecl{Name: a.curLex.Text}
+	if a.checkNext(lexer.OPEN_PAREN) {
+		a.expect(lexer.IDENTIFIER)
+		decl.Parent = &ast.Ident{Lexeme: *a.curLex}
+		a.expect(lexer.CLOSE_PAREN)
+	}
+	a.expect(lexer.OPEN_CURLY)
+	for a.before(lexer.CLOSE_CURLY) {
+		a.expect(lexer.FUNCTION_T)
+		decl.Methods = append(decl.Methods, a.parseFunction().(ast.FunctionLiteral))
+	}
+	a.advance()
+	return decl
+}
+
+func (a *Parser) parseEnum() ast.Node {
+	a.expect(lexer.IDENTIFIER)
+	decl := ast.EnumDecl{Name: a.curLex.Text}
+	a.expect(lexer.OPEN_CURLY)
+	for a.before(lexer.CLOSE_CURLY) {
+		if a.checkNext(lexer.COMMA_SYM) {
+			continue
+		}
+		a.expect(lexer.IDENTIFIER)
+		variant := ast.EnumVariantDecl{Name: a.curLex.Text}
+		if a.checkNext(lexer.OPEN_PAREN) {
+			for a.before(lexer.CLOSE_PAREN) {
+				if a.checkNext(lexer.COMMA_SYM) {
+					continue
+				}
+				a.expect(lexer.IDENTIFIER)
+				variant.Fields = append(variant.Fields, a.curLex.Text)
+			}
+			a.advance()
+		}
+		decl.Variants = append(decl.Variants, variant)
+	}
+	a.advance()
+	return decl
+}
+
+func (a *Parser) parseMatch() ast.Node {
+	a.advance()
+	m := ast.MatchExpr{Subject: a.parseExpr(LOWEST_PREC)}
+	a.expect(lexer.OPEN_CURLY)
+	for a.before(lexer.CLOSE_CURLY) {
+		a.advance()
+		if a.curLex.Kind == lexer.COMMA_SYM {
+			continue
+		}
+		arm := ast.MatchArm{Pattern: a.parseExpr(LOWEST_PREC)}
+		a.expect(lexer.ARROW_SYM)
+		if a.checkNext(lexer.OPEN_CURLY) {
+			arm.Body = a.parseBlock()
+		} else {
+			a.advance()
+			arm.Body = a.parseExpr(LOWEST_PREC)
+		}
+		m.Arms = append(m.Arms, arm)
+	}
+	a.advance()
+	return m
+}
+
+func (a *Parser) parseCall(function ast.Node) ast.Node {
+	return ast.CallExpr{
+		Function: function,
+		Args:     a.parseArgList(),
+	}
+}
+
+func (a *Parser) parseSwap() ast.Node {
+	a.expect(lexer.OPEN_PAREN)
+	a.advance()
+	swap := ast.SwapStmt{
+		A: a.parseExpr(LOWEST_PREC),
+	}
+	a.expect(lexer.COMMA_SYM)
+	a.advance()
+	swap.B = a.parseExpr(LOWEST_PREC)
+	a.expect(lexer.CLOSE_PAREN)
+	return swap
+}
+
+func (a *Parser) parseImport() ast.Node {
+	a.expect(lexer.OPEN_PAREN)
+	a.advance()
+	imp := ast.ImportStmt{
+		File: a.parseExpr(LOWEST_PREC),
+	}
+	a.expect(lexer.CLOSE_PAREN)
+	return imp
+}
+
+func (a *Parser) parseInput() ast.Node {
+	a.expect(lexer.OPEN_PAREN)
+	a.advance()
+	inp := ast.InputStmt{
+		Prompt: a.parseExpr(LOWEST_PREC),
+	}
+	a.expect(lexer.CLOSE_PAREN)
+	return inp
+}
+
+func (a *Parser) parseLen() ast.Node {
+	a.expect(lexer.OPEN_PAREN)
+	a.advance()
+	ln := ast.LengthExpr{Target: a.parseExpr(LOWEST_PREC)}
+	a.expect(lexer.CLOSE_PAREN)
+	return ln
+}
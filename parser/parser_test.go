@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"enum variant", "enum E { A, 1 }", "syntax error on line 1: expected a name, found 1"},
+		{"enum field", "enum E {\n  A(x, \"y\")\n}", `syntax error on line 2: expected a name, found "y"`},
+		{"struct field", "struct P { x, 2 }", "syntax error on line 1: expected a name, found 2"},
+		{"unclosed enum", "enum E { A", "syntax error on line 1: expected }, found end of input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			var serr *SyntaxError
+			if !errors.As(err, &serr) || err.Error() != tt.want {
+				t.Errorf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateSourceParser(t *testing.T) {
+	p := CreateSourceParser("a = 1\nb = [a]\nfn f( { }")
+	n := 0
+	for range p.Nodes {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d statements before the error, want 2", n)
+	}
+	var serr *SyntaxError
+	if err := p.Err(); !errors.As(err, &serr) || serr.Line != 3 {
+		t.Errorf("Err() = %v, want a syntax error on line 3", err)
+	}
+}
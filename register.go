@@ -0,0 +1,80 @@
+package goscript
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/basemax/goscript/eval"
+)
+
+// Value is a script value: nil, int, *big.Int, float64, eval.Decimal,
+// string, bool, *eval.Array, eval.Tuple, *eval.Map or another runtime type.
+type Value = any
+
+var errorType = reflect.TypeFor[error]()
+
+// RegisterFunc exposes fn to scripts under name. It is resolved like a
+// script-defined function and shadows a builtin of the same name.
+func (in *Interpreter) RegisterFunc(name string, fn func(args []Value) (Value, error)) {
+	in.env.SetFunction(name, eval.NewBuiltin(name, fn))
+}
+
+// RegisterGoFunc exposes an arbitrary Go function to scripts, converting
+// arguments and results by reflection. The function may return nothing, a
+// value, an error, or a value and an error.
+func (in *Interpreter) RegisterGoFunc(name string, fn any) error {
+	f := reflect.ValueOf(fn)
+	t := f.Type()
+	if t.Kind() != reflect.Func {
+		return fmt.Errorf("register %s: %T is not a function", name, fn)
+	}
+	returnsErr := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+	if t.NumOut() > 2 || (t.NumOut() == 2 && !returnsErr) {
+		return fmt.Errorf("register %s: function must return at most a value and an error", name)
+	}
+	in.RegisterFunc(name, func(args []Value) (Value, error) {
+		goArgs, err := convertArgs(t, args)
+		if err != nil {
+			return nil, err
+		}
+		out := f.Call(goArgs)
+		if returnsErr {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, err
+			}
+			out = out[:len(out)-1]
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return toValue(out[0])
+	})
+	return nil
+}
+
+func convertArgs(t reflect.Type, args []Value) ([]reflect.Value, error) {
+	fixed := t.NumIn()
+	if t.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return nil, fmt.Errorf("expects at least %d arguments, got %d", fixed, len(args))
+		}
+	} else if len(args) != fixed {
+		return nil, fmt.Errorf("expects %d arguments, got %d", fixed, len(args))
+	}
+	goArgs := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var pt reflect.Type
+		if i < fixed {
+			pt = t.In(i)
+		} else {
+			pt = t.In(fixed).Elem()
+		}
+		v, err := fromValue(arg, pt)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		goArgs[i] = v
+	}
+	return goArgs, nil
+}